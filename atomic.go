@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// atomicStorage is the variable of a sync/atomic type storing the values of
+// a variable in atomic mode.
+type atomicStorage struct {
+	name string
+	// typ is the sync/atomic type, and valueType is the type the values are
+	// converted to for it, which is empty if they aren't converted.
+	typ, valueType string
+	// boxed reports whether the values are stored as the pointers to their
+	// copies in atomic.Pointer, since they have no sync/atomic type.
+	boxed bool
+	// varType is the type of the variable.
+	varType string
+}
+
+// to converts the value of the variable to the value of the storage.
+func (s atomicStorage) to(v string) string {
+	switch {
+	case s.boxed:
+		return "&" + v
+	case s.typ == "Value":
+		return fmt.Sprintf("%s{%s}", s.valueWrapper(), v)
+	case s.valueType != "" && s.valueType != s.varType:
+		return fmt.Sprintf("%s(%s)", s.valueType, v)
+	}
+	return v
+}
+
+// from converts the value of the storage to the value of the variable.
+func (s atomicStorage) from(v string) string {
+	switch {
+	case s.boxed:
+		return "*" + v
+	case s.typ == "Value":
+		return fmt.Sprintf("%s.(%s).value", v, s.valueWrapper())
+	case s.valueType != "" && s.valueType != s.varType:
+		return fmt.Sprintf("%s(%s)", s.varType, v)
+	}
+	return v
+}
+
+// valueWrapper is the type storing the interfaces in atomic.Value, which keeps
+// the stored type the same and allows nil.
+func (s atomicStorage) valueWrapper() string {
+	return fmt.Sprintf("struct{ value %s }", s.varType)
+}
+
+// atomicStorage returns the storage of the variable in atomic mode. The values
+// without a sync/atomic type are boxed in update mode, whose updates don't
+// compare the values.
+func (g *Generator) atomicStorage() (atomicStorage, error) {
+	s := atomicStorage{typ: g.AtomicType, valueType: g.AtomicValueType, varType: g.Type}
+	if g.opts.update && (s.typ == "" || s.typ == "Value") {
+		s = atomicStorage{typ: "Pointer[" + g.Type + "]", boxed: true, varType: g.Type}
+	}
+	if s.typ == "" {
+		return s, fmt.Errorf("can't store '%s' atomically, whose type %s isn't a pointer, integer, bool or interface", g.Name, g.Type)
+	}
+	// the variable isn't updated by the accessors, so it would be stale
+	if len(g.Uses) > 0 {
+		return s, fmt.Errorf("can't store '%s' atomically, which is used directly at %s", g.Name, strings.Join(g.Uses, ", "))
+	}
+
+	name := lower(g.Name) + "Atomic"
+	storage, err := g.resolve(name, "variable")
+	if err != nil {
+		return s, err
+	}
+	if storage == "" {
+		return s, fmt.Errorf("%s already exists, which stores '%s' atomically", name, g.Name)
+	}
+	s.name = storage
+	return s, nil
+}
+
+// getAtomicCodeLines returns the accessors of the variable in atomic mode,
+// which store its values in a sync/atomic type declared for it. The storage is
+// initialized with the variable in its declaration, and the variable isn't
+// accessed after that. Besides the getter and the setter, SwapX and
+// CompareAndSwapX are generated with the setter, and UpdateX in update mode.
+func (g *Generator) getAtomicCodeLines() (cl codeLines, err error) {
+	s, err := g.atomicStorage()
+	if err != nil {
+		return nil, err
+	}
+	declared := func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	}
+	atomicName := g.AddImport("atomic", "sync/atomic", declared)
+
+	// the storage is initialized in its declaration, so the accessors can be
+	// called by the initialization of the package, including init functions
+	storage := "storage"
+	if g.Name == storage {
+		storage = "store"
+	}
+	cl = cl.Append("")
+	cl = cl.Append("// %s stores %s atomically, which is initialized with %s.", s.name, g.Name, g.Name)
+	cl = cl.Append("// %s must not be used directly after that, since the accessors don't update it.", g.Name)
+	cl = cl.Append("var %s = func() *%s.%s {", s.name, atomicName, s.typ)
+	cl = cl.Append("        var %s %s.%s", storage, atomicName, s.typ)
+	if s.boxed {
+		// the variable itself isn't shared with the storage
+		cl = cl.Append("        %s := %s", g.newValueName(), g.Name)
+		cl = cl.Append("        %s.Store(%s)", storage, s.to(g.newValueName()))
+	} else {
+		cl = cl.Append("        %s.Store(%s)", storage, s.to(g.Name))
+	}
+	cl = cl.Append("        return &%s", storage)
+	cl = cl.Append("}()")
+
+	v := g.newValueName()
+	accessors := []struct {
+		enabled   bool
+		name      string
+		signature string
+		body      codeLines
+	}{
+		{g.opts.getter, concat(g.GetPrefix(), g.opts.prefix, g.Name), fmt.Sprintf("() %s", g.Type),
+			codeLines{}.Append("        return %s", s.from(s.name+".Load()"))},
+		{g.opts.setter, concat("set", g.opts.prefix, g.Name), fmt.Sprintf("(%s %s)", v, g.Type),
+			codeLines{}.Append("        %s.Store(%s)", s.name, s.to(v))},
+		{g.opts.setter, concat("swap", g.opts.prefix, g.Name), fmt.Sprintf("(%s %s) %s", v, g.Type, g.Type),
+			codeLines{}.Append("        return %s", s.from(fmt.Sprintf("%s.Swap(%s)", s.name, s.to(v))))},
+		{g.opts.setter && (!s.boxed || g.Comparable), concat("compareAndSwap", g.opts.prefix, g.Name), fmt.Sprintf("(old, %s %s) bool", v, g.Type),
+			g.compareAndSwapCodeLines(s, v)},
+	}
+	if g.opts.update {
+		signature, body := g.updateCodeLines(s, v, declared)
+		accessors = append(accessors, struct {
+			enabled   bool
+			name      string
+			signature string
+			body      codeLines
+		}{true, concat("update", g.opts.prefix, g.Name), signature, body})
+	}
+
+	for _, accessor := range accessors {
+		if !accessor.enabled {
+			continue
+		}
+		funcName, err := g.resolveName(accessor.name)
+		if err != nil {
+			return nil, err
+		}
+		cl = cl.Append("")
+		if funcName == "" {
+			cl = cl.Append("// %s already exists", accessor.name)
+			continue
+		}
+		cl = cl.Append("func %s%s {", funcName, accessor.signature)
+		cl = append(cl, accessor.body...)
+		cl = cl.Append("}")
+	}
+	return
+}
+
+// compareAndSwapCodeLines returns the body of CompareAndSwapX, which compares
+// the boxed values instead of their pointers.
+func (g *Generator) compareAndSwapCodeLines(s atomicStorage, v string) (cl codeLines) {
+	if !s.boxed {
+		return cl.Append("        return %s.CompareAndSwap(%s, %s)", s.name, s.to("old"), s.to(v))
+	}
+	cl = cl.Append("        for {")
+	cl = cl.Append("                p := %s.Load()", s.name)
+	cl = cl.Append("                if *p != old {")
+	cl = cl.Append("                        return false")
+	cl = cl.Append("                }")
+	cl = cl.Append("                if %s.CompareAndSwap(p, %s) {", s.name, s.to(v))
+	cl = cl.Append("                        return true")
+	cl = cl.Append("                }")
+	cl = cl.Append("        }")
+	return
+}
+
+// updateCodeLines returns the signature and the body of UpdateX, which applies
+// the function to the current value and stores the result unless the value
+// is changed by others, in which case it retries with the new value. It takes
+// a context checked before each attempt in updateContext mode, and gives up
+// after updateRetries attempts if it's positive.
+func (g *Generator) updateCodeLines(s atomicStorage, v string, declared func(string) bool) (signature string, cl codeLines) {
+	fails := g.opts.updateContext || g.opts.updateRetries > 0
+	signature = fmt.Sprintf("(fn func(old %s) %s) %s", g.Type, g.Type, g.Type)
+	if g.opts.updateContext {
+		contextName := g.AddImport("context", "context", declared)
+		signature = fmt.Sprintf("(ctx %s.Context, fn func(old %s) %s) (%s, error)", contextName, g.Type, g.Type, g.Type)
+	} else if fails {
+		signature = fmt.Sprintf("(fn func(old %s) %s) (%s, error)", g.Type, g.Type, g.Type)
+	}
+
+	if g.opts.updateRetries > 0 {
+		cl = cl.Append("        for i := 0; i < %d; i++ {", g.opts.updateRetries)
+	} else {
+		cl = cl.Append("        for {")
+	}
+	if g.opts.updateContext {
+		cl = cl.Append("                if err := ctx.Err(); err != nil {")
+		cl = cl.Append("                        var zero %s", g.Type)
+		cl = cl.Append("                        return zero, err")
+		cl = cl.Append("                }")
+	}
+	cl = cl.Append("                old := %s.Load()", s.name)
+	cl = cl.Append("                %s := fn(%s)", v, s.from("old"))
+	cl = cl.Append("                if %s.CompareAndSwap(old, %s) {", s.name, s.to(v))
+	if fails {
+		cl = cl.Append("                        return %s, nil", v)
+	} else {
+		cl = cl.Append("                        return %s", v)
+	}
+	cl = cl.Append("                }")
+	cl = cl.Append("        }")
+	if g.opts.updateRetries > 0 {
+		errorsName := g.AddImport("errors", "errors", declared)
+		cl = cl.Append("        var zero %s", g.Type)
+		cl = cl.Append("        return zero, %s.New(\"%s: the update isn't applied after %d attempts\")", errorsName, concat("update", g.opts.prefix, g.Name), g.opts.updateRetries)
+	}
+	return
+}
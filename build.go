@@ -0,0 +1,152 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// buildConfig is the build configuration the package is loaded with, empty
+// values fall back to the ones of the go command.
+type buildConfig struct {
+	goos   string
+	goarch string
+	tags   []string
+}
+
+// matchFile reports whether the file is included in the build of the
+// configuration, according to its name and build constraint.
+func (b buildConfig) matchFile(path string) (bool, error) {
+	ctx := build.Default
+	if b.goos != "" {
+		ctx.GOOS = b.goos
+	}
+	if b.goarch != "" {
+		ctx.GOARCH = b.goarch
+	}
+	ctx.BuildTags = b.tags
+	return ctx.MatchFile(filepath.Dir(path), filepath.Base(path))
+}
+
+// isGeneratedFile reports whether the file is generated by goaccessor.
+func isGeneratedFile(filename string) bool {
+	return strings.HasSuffix(filename, "_goaccessor.go") || strings.HasSuffix(filename, "_goaccessor_test.go")
+}
+
+// fileConstraint returns the build constraint of the file, which combines the
+// //go:build line (or the // +build lines of old files) and the GOOS and
+// GOARCH implied by the file name. It returns nil if the file has none.
+func fileConstraint(file *ast.File, filename string) (constraint.Expr, error) {
+	var goBuild, plusBuild constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil, err
+				}
+				goBuild = expr
+			case constraint.IsPlusBuild(c.Text):
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil, err
+				}
+				plusBuild = and(plusBuild, expr)
+			}
+		}
+	}
+
+	expr := goBuild
+	if expr == nil {
+		expr = plusBuild
+	}
+	return and(expr, nameConstraint(filename)), nil
+}
+
+// nameConstraint returns the build constraint implied by the file name, like
+// *_GOOS, *_GOARCH and *_GOOS_GOARCH, following the rules of go/build.
+func nameConstraint(filename string) constraint.Expr {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	// the first element is never a constraint, e.g. linux.go
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]] {
+		return and(&constraint.TagExpr{Tag: l[n-2]}, &constraint.TagExpr{Tag: l[n-1]})
+	}
+	if tag := l[len(l)-1]; knownOS[tag] || knownArch[tag] {
+		return &constraint.TagExpr{Tag: tag}
+	}
+	return nil
+}
+
+// and returns x && y, either of them may be nil.
+func and(x, y constraint.Expr) constraint.Expr {
+	if x == nil {
+		return y
+	}
+	if y == nil {
+		return x
+	}
+	return &constraint.AndExpr{X: x, Y: y}
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH recognized in file
+// names, "unix" is left out as it is only a build tag.
+var knownOS = map[string]bool{
+	"aix":       true,
+	"android":   true,
+	"darwin":    true,
+	"dragonfly": true,
+	"freebsd":   true,
+	"hurd":      true,
+	"illumos":   true,
+	"ios":       true,
+	"js":        true,
+	"linux":     true,
+	"nacl":      true,
+	"netbsd":    true,
+	"openbsd":   true,
+	"plan9":     true,
+	"solaris":   true,
+	"wasip1":    true,
+	"windows":   true,
+	"zos":       true,
+}
+
+var knownArch = map[string]bool{
+	"386":         true,
+	"amd64":       true,
+	"amd64p32":    true,
+	"arm":         true,
+	"armbe":       true,
+	"arm64":       true,
+	"arm64be":     true,
+	"loong64":     true,
+	"mips":        true,
+	"mipsle":      true,
+	"mips64":      true,
+	"mips64le":    true,
+	"mips64p32":   true,
+	"mips64p32le": true,
+	"ppc":         true,
+	"ppc64":       true,
+	"ppc64le":     true,
+	"riscv":       true,
+	"riscv64":     true,
+	"s390":        true,
+	"s390x":       true,
+	"sparc":       true,
+	"sparc64":     true,
+	"wasm":        true,
+}
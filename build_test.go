@@ -0,0 +1,79 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFileConstraint(t *testing.T) {
+	type testCase struct {
+		filename string
+		src      string
+		output   string
+	}
+	for _, tc := range []testCase{
+		{
+			filename: "plain.go",
+			src:      "package p\n",
+			output:   "",
+		},
+		{
+			filename: "linux.go",
+			src:      "package p\n",
+			output:   "",
+		},
+		{
+			filename: "file_linux.go",
+			src:      "package p\n",
+			output:   "linux",
+		},
+		{
+			filename: "file_linux_amd64_test.go",
+			src:      "package p\n",
+			output:   "linux && amd64",
+		},
+		{
+			filename: "file_unix.go",
+			src:      "package p\n",
+			output:   "",
+		},
+		{
+			filename: "file_arm64.go",
+			src:      "//go:build tools || (debug && !race)\n\npackage p\n",
+			output:   "(tools || (debug && !race)) && arm64",
+		},
+		{
+			filename: "file.go",
+			src:      "// +build linux darwin\n// +build cgo\n\npackage p\n",
+			output:   "(linux || darwin) && cgo",
+		},
+		{
+			filename: "file.go",
+			src:      "//go:build linux\n// +build darwin\n\npackage p\n",
+			output:   "linux",
+		},
+		{
+			filename: "file.go",
+			src:      "package p\n\n//go:build linux\n",
+			output:   "",
+		},
+	} {
+		file, err := parser.ParseFile(token.NewFileSet(), tc.filename, tc.src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("parser.ParseFile %s: %s", tc.filename, err.Error())
+		}
+		expr, err := fileConstraint(file, tc.filename)
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.filename, err.Error())
+			continue
+		}
+		got := ""
+		if expr != nil {
+			got = expr.String()
+		}
+		if got != tc.output {
+			t.Errorf("got %q for %s, expected %q", got, tc.filename, tc.output)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// builderMethod sets a field of the struct in the builder.
+type builderMethod struct {
+	name  string
+	field Field
+}
+
+// getBuilderCodeLines returns the builder of the struct type in builder mode,
+// which sets the selected fields one by one and builds a new struct with them.
+// Build reports the required fields which aren't set. The builder and its
+// constructor are named after the struct type, and are skipped together if
+// the builder already exists.
+func (g *Generator) getBuilderCodeLines() (cl codeLines, err error) {
+	name := g.Name + "Builder"
+	builderName, err := g.resolveTypeName(name)
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if builderName == "" {
+		return cl.Append("// %s already exists", name), nil
+	}
+
+	constructor := "New" + upper(g.Name) + "Builder"
+	if !token.IsExported(g.Name) {
+		constructor = lower(constructor)
+	}
+	constructorName, err := g.resolve(constructor, "function")
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []builderMethod
+	var required []string
+	names := map[string]bool{"Build": true}
+	for _, field := range g.Fields {
+		// the promoted fields are set through their embedded structs
+		if !g.isFieldSelected(field) || field.Promoted || field.LockKind != "" {
+			continue
+		}
+		method := g.modifierName("", field)
+		if names[method] {
+			return nil, fmt.Errorf("%s of field %s is declared more than once by the builder of '%s'", method, field.Name, g.Name)
+		}
+		names[method] = true
+		methods = append(methods, builderMethod{name: method, field: field})
+		if field.Config != nil && field.Config.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	builderType, typeParams := g.instantiate(builderName), g.getTypeParamList()
+	recv := initial(builderName)
+	v := "v"
+	if recv == v {
+		v = "val"
+	}
+
+	cl = cl.Append("// %s builds %s field by field.", builderName, g.Name)
+	cl = cl.Append("type %s%s struct {", builderName, typeParams)
+	cl = cl.Append("        value %s", g.getReceiverType())
+	if len(required) > 0 {
+		cl = cl.Append("        // set records the required fields which are set.")
+		cl = cl.Append("        set struct {")
+		for _, field := range required {
+			cl = cl.Append("                %s bool", field)
+		}
+		cl = cl.Append("        }")
+	}
+	cl = cl.Append("}")
+	cl = cl.Append("")
+	if constructorName == "" {
+		cl = cl.Append("// %s already exists", constructor)
+	} else {
+		cl = cl.Append("// %s returns a builder of %s.", constructorName, g.Name)
+		cl = cl.Append("func %s%s() *%s {", constructorName, typeParams, builderType)
+		cl = cl.Append("        return &%s{}", builderType)
+		cl = cl.Append("}")
+	}
+
+	for _, m := range methods {
+		cl = cl.Append("")
+		cl = cl.Append("func (%s *%s) %s(%s %s) *%s {", recv, builderType, m.name, v, m.field.Type, builderType)
+		value := v
+		if g.cloned(m.field) {
+			if value = g.cloneExpr(m.field, v); value == "" {
+				value = "clone"
+				cl = cl.Append("        var %s %s", value, m.field.Type)
+				cl = append(cl, g.cloneCodeLines(m.field, m.field.Type, v, value)...)
+			}
+		}
+		cl = cl.Append("        %s.value.%s = %s", recv, m.field.Name, value)
+		if m.field.Config != nil && m.field.Config.Required {
+			cl = cl.Append("        %s.set.%s = true", recv, m.field.Name)
+		}
+		cl = cl.Append("        return %s", recv)
+		cl = cl.Append("}")
+	}
+
+	cl = cl.Append("")
+	cl = cl.Append("// Build returns a new %s with the fields set, or an error if any required", g.Name)
+	cl = cl.Append("// field isn't set.")
+	cl = cl.Append("func (%s *%s) Build() (*%s, error) {", recv, builderType, g.getReceiverType())
+	if len(required) > 0 {
+		fmtName := g.AddImport("fmt", "fmt", func(name string) bool {
+			_, ok := g.Declared[name]
+			return ok
+		})
+		cl = cl.Append("        var missing []string")
+		for _, field := range required {
+			cl = cl.Append("        if !%s.set.%s {", recv, field)
+			cl = cl.Append("                missing = append(missing, %q)", field)
+			cl = cl.Append("        }")
+		}
+		cl = cl.Append("        if len(missing) > 0 {")
+		cl = cl.Append("                return nil, %s.Errorf(\"%s: missing required fields %%v\", missing)", fmtName, builderName)
+		cl = cl.Append("        }")
+	}
+	// the fields are copied one by one, which leaves the locks alone, and
+	// cloned in copy mode, so the built values don't share them with each other
+	var cloned []Field
+	literal := codeLines{}
+	for _, m := range methods {
+		value := recv + ".value." + m.field.Name
+		if g.cloned(m.field) {
+			if value = g.cloneExpr(m.field, value); value == "" {
+				cloned = append(cloned, m.field)
+				continue
+			}
+		}
+		literal = literal.Append("                %s: %s,", m.field.Name, value)
+	}
+	if len(cloned) == 0 {
+		cl = cl.Append("        return &%s{", g.getReceiverType())
+		cl = append(cl, literal...)
+		cl = cl.Append("        }, nil")
+		cl = cl.Append("}")
+		return
+	}
+	cl = cl.Append("        built := &%s{", g.getReceiverType())
+	cl = append(cl, literal...)
+	cl = cl.Append("        }")
+	for _, field := range cloned {
+		cl = append(cl, g.cloneCodeLines(field, field.Type, recv+".value."+field.Name, "built."+field.Name)...)
+	}
+	cl = cl.Append("        return built, nil")
+	cl = cl.Append("}")
+	return
+}
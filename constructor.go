@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// constructorParam is a parameter of the constructor setting a field.
+type constructorParam struct {
+	name  string
+	field Field
+}
+
+// getConstructorCodeLines returns the constructor of the struct type in
+// constructor mode, whose parameters set the selected fields in declaration
+// order except the optional ones, which are left with their default values.
+// NewBookFromFields taking the fields in a BookFields struct is generated as
+// well in constructorFields mode.
+func (g *Generator) getConstructorCodeLines() (cl codeLines, err error) {
+	var fields, defaults []Field
+	for _, field := range g.Fields {
+		if field.Promoted || field.LockKind != "" {
+			continue
+		}
+		optional := field.Config != nil && field.Config.Optional
+		if g.isFieldSelected(field) && !optional {
+			fields = append(fields, field)
+		} else if field.Config != nil && field.Config.Default != "" {
+			defaults = append(defaults, field)
+		}
+	}
+
+	params := make([]constructorParam, 0, len(fields))
+	// the receiver name holds the new struct if its fields are cloned
+	names := map[string]bool{g.getReceiverName(): true}
+	for _, field := range fields {
+		params = append(params, constructorParam{name: g.paramName(field, names), field: field})
+	}
+
+	constructor := g.constructorName("")
+	constructorName, err := g.resolve(constructor, "function")
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if constructorName == "" {
+		cl = cl.Append("// %s already exists", constructor)
+	} else {
+		signature := make([]string, len(params))
+		for i, p := range params {
+			signature[i] = p.name + " " + p.field.Type
+		}
+		cl = cl.Append("// %s returns a new %s with the fields set.", constructorName, g.Name)
+		cl = cl.Append("func %s%s(%s) *%s {", constructorName, g.getTypeParamList(), strings.Join(signature, ", "), g.getReceiverType())
+		cl = append(cl, g.constructCodeLines(params, defaults, func(p constructorParam) string {
+			return p.name
+		})...)
+		cl = cl.Append("}")
+	}
+
+	if !g.opts.constructorFields {
+		return cl, nil
+	}
+	fieldsCodeLines, err := g.getConstructorFieldsCodeLines()
+	if err != nil {
+		return nil, err
+	}
+	return append(cl, fieldsCodeLines...), nil
+}
+
+// getConstructorFieldsCodeLines returns the BookFields struct holding the
+// selected fields including the optional ones, and NewBookFromFields building
+// the struct type with them, which suits the structs with many fields.
+func (g *Generator) getConstructorFieldsCodeLines() (cl codeLines, err error) {
+	name := g.Name + "Fields"
+	fieldsName, err := g.resolveTypeName(name)
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if fieldsName == "" {
+		return cl.Append("// %s already exists", name), nil
+	}
+
+	var params []constructorParam
+	for _, field := range g.Fields {
+		if g.isFieldSelected(field) && !field.Promoted && field.LockKind == "" {
+			params = append(params, constructorParam{name: field.Name, field: field})
+		}
+	}
+
+	cl = cl.Append("// %s holds the fields of %s to build.", fieldsName, g.Name)
+	cl = cl.Append("type %s%s struct {", fieldsName, g.getTypeParamList())
+	for _, p := range params {
+		cl = cl.Append("        %s %s", p.name, p.field.Type)
+	}
+	cl = cl.Append("}")
+
+	constructor := g.constructorName("FromFields")
+	constructorName, err := g.resolve(constructor, "function")
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if constructorName == "" {
+		return cl.Append("// %s already exists", constructor), nil
+	}
+	cl = cl.Append("// %s returns a new %s with the fields.", constructorName, g.Name)
+	cl = cl.Append("func %s%s(fields %s) *%s {", constructorName, g.getTypeParamList(), g.instantiate(fieldsName), g.getReceiverType())
+	cl = append(cl, g.constructCodeLines(params, nil, func(p constructorParam) string {
+		return "fields." + p.name
+	})...)
+	cl = cl.Append("}")
+	return
+}
+
+// constructorName returns the name of the constructor of the struct type with
+// the suffix, which is unexported if the struct type is.
+func (g *Generator) constructorName(suffix string) string {
+	name := "New" + upper(g.Name) + suffix
+	if !token.IsExported(g.Name) {
+		return lower(name)
+	}
+	return name
+}
+
+// paramName returns the name of the parameter setting the field, which is the
+// field name in lower case, and isn't a keyword, an imported package name, or
+// a name in names.
+func (g *Generator) paramName(field Field, names map[string]bool) string {
+	name := lower(field.Name)
+	if token.IsKeyword(name) || g.isImportName(name) {
+		name += "Value"
+	}
+	for i, base := 2, name; names[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	names[name] = true
+	return name
+}
+
+// isImportName reports whether the name refers to an imported package in the
+// generated file.
+func (g *Generator) isImportName(name string) bool {
+	for _, i := range g.Imports {
+		if i.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// constructCodeLines returns the statements returning a new struct, whose
+// fields are set in declaration order by the values of the parameters or the
+// default values. The values are cloned in copy mode.
+func (g *Generator) constructCodeLines(params []constructorParam, defaults []Field, value func(constructorParam) string) (cl codeLines) {
+	values := make(map[string]string, len(params)+len(defaults))
+	for _, field := range defaults {
+		values[field.Name] = field.Config.Default
+	}
+	recv := g.getReceiverName()
+	var cloned codeLines
+	for _, p := range params {
+		src := value(p)
+		v := src
+		if g.cloned(p.field) {
+			if v = g.cloneExpr(p.field, src); v == "" {
+				cloned = append(cloned, g.cloneCodeLines(p.field, p.field.Type, src, recv+"."+p.field.Name)...)
+				continue
+			}
+		}
+		values[p.field.Name] = v
+	}
+
+	literal := "return &"
+	if len(cloned) > 0 {
+		literal = recv + " := &"
+	}
+	cl = cl.Append("        %s%s{", literal, g.getReceiverType())
+	for _, field := range g.Fields {
+		if v, ok := values[field.Name]; ok && !field.Promoted {
+			cl = cl.Append("                %s: %s,", field.Name, v)
+		}
+	}
+	cl = cl.Append("        }")
+	if len(cloned) > 0 {
+		cl = append(cl, cloned...)
+		cl = cl.Append("        return %s", recv)
+	}
+	return
+}
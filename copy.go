@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deepClone reports whether the field is cloned by the Clone methods of its
+// values or elements, which return pointers to their copies like the
+// generated ones.
+func deepClone(field Field) bool {
+	return field.Config != nil && field.Config.Clone
+}
+
+// cloned reports whether the values of the field are cloned by the accessors
+// in copy mode. The slices and maps are always cloned, and the arrays are
+// cloned if their elements are pointers, while the other fields are only
+// cloned deeply.
+func (g *Generator) cloned(field Field) bool {
+	if !g.opts.copy || field.LockKind != "" {
+		return false
+	}
+	switch field.CopyKind {
+	case "slice", "map":
+		return true
+	case "array":
+		return field.ElemPointer || deepClone(field)
+	}
+	return deepClone(field)
+}
+
+// cloneName returns the name of the variable holding the clone, which doesn't
+// shadow the target.
+func (g *Generator) cloneName() string {
+	if g.Name != "clone" && g.getReceiverName() != "clone" {
+		return "clone"
+	}
+	return "cloned"
+}
+
+// cloneExpr returns the expression cloning src, or "" if the clone is built by
+// the statements of cloneCodeLines.
+func (g *Generator) cloneExpr(field Field, src string) string {
+	deep := deepClone(field)
+	declared := func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	}
+	switch {
+	case field.CopyKind == "slice" && !deep:
+		return fmt.Sprintf("%s.Clone(%s)", g.AddImport("slices", "slices", declared), src)
+	case field.CopyKind == "map" && !deep:
+		return fmt.Sprintf("%s.Clone(%s)", g.AddImport("maps", "maps", declared), src)
+	case field.CopyKind == "" && deep:
+		return cloneElem(field, src)
+	}
+	return ""
+}
+
+// cloneElem returns the expression cloning the value e of the element of the
+// field, or of the field itself, by its Clone method.
+func cloneElem(field Field, e string) string {
+	if field.ElemPointer {
+		return e + ".Clone()"
+	}
+	return "*" + e + ".Clone()"
+}
+
+// cloneCodeLines returns the statements cloning src of type typ into dst,
+// which is the zero value before them. The pointers in a shallow cloned array
+// point to the copies of their values, and the elements of a deep cloned one
+// are cloned by their Clone methods, so are the ones of the slices and maps.
+func (g *Generator) cloneCodeLines(field Field, typ, src, dst string) (cl codeLines) {
+	if expr := g.cloneExpr(field, src); expr != "" {
+		return cl.Append("        %s = %s", dst, expr)
+	}
+
+	if field.CopyKind == "array" {
+		cl = cl.Append("        for i, e := range %s {", src)
+		if deepClone(field) {
+			cl = cl.Append("                %s[i] = %s", dst, cloneElem(field, "e"))
+		} else {
+			cl = cl.Append("                if e != nil {")
+			cl = cl.Append("                        elem := *e")
+			cl = cl.Append("                        %s[i] = &elem", dst)
+			cl = cl.Append("                }")
+		}
+		cl = cl.Append("        }")
+		return
+	}
+
+	cl = cl.Append("        if %s != nil {", src)
+	cl = cl.Append("                %s = make(%s, len(%s))", dst, typ, src)
+	cl = cl.Append("                for k, e := range %s {", src)
+	cl = cl.Append("                        %s[k] = %s", dst, cloneElem(field, "e"))
+	cl = cl.Append("                }")
+	cl = cl.Append("        }")
+	return
+}
+
+// getterCloneCodeLines returns the statements returning the clone of the
+// field, whose value is src.
+func (g *Generator) getterCloneCodeLines(field Field, typ, src string) (cl codeLines) {
+	if expr := g.cloneExpr(field, src); expr != "" {
+		return cl.Append("        return %s", expr)
+	}
+	clone := g.cloneName()
+	cl = cl.Append("        var %s %s", clone, typ)
+	cl = append(cl, g.cloneCodeLines(field, typ, src, clone)...)
+	cl = cl.Append("        return %s", clone)
+	return
+}
+
+// setterCloneCodeLines returns the statements cloning the new value of the
+// field before the lock is acquired, and the expression of the clone to store.
+func (g *Generator) setterCloneCodeLines(field Field, typ string) (cl codeLines, expr string) {
+	v := g.newValueName()
+	if expr := g.cloneExpr(field, v); expr != "" {
+		return nil, expr
+	}
+	clone := g.cloneName()
+	cl = cl.Append("        var %s %s", clone, typ)
+	cl = append(cl, g.cloneCodeLines(field, typ, v, clone)...)
+	return cl, clone
+}
+
+// getCloneCodeLines returns the Clone method of the struct type in copy mode,
+// which returns a copy of the receiver whose fields are cloned like the ones
+// returned by the getters, so a nil receiver returns nil. The lock fields of
+// the copy are left unlocked, and it's built under the lock in sync mode.
+func (g *Generator) getCloneCodeLines() (cl codeLines, err error) {
+	name, err := g.resolveName("Clone")
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if name == "" {
+		return cl.Append("// Clone already exists"), nil
+	}
+
+	recv, clone := g.getReceiverName(), g.cloneName()
+	var cloned []Field
+	cl = cl.Append("func (%s *%s) %s() *%s {", recv, g.getReceiverType(), name, g.getReceiverType())
+	cl = cl.Append("        if %s == nil {", recv)
+	cl = cl.Append("                return nil")
+	cl = cl.Append("        }")
+	cl = append(cl, g.lock.codeLines(false)...)
+	cl = cl.Append("        %s := &%s{", clone, g.getReceiverType())
+	for _, field := range g.Fields {
+		switch {
+		case field.Promoted || field.Name == "_":
+		case field.LockKind != "" && !strings.HasPrefix(field.Type, "*"):
+			// the locks can't be copied
+		case g.cloned(field):
+			cloned = append(cloned, field)
+		default:
+			cl = cl.Append("                %s: %s.%s,", field.Name, recv, field.Name)
+		}
+	}
+	cl = cl.Append("        }")
+	for _, field := range cloned {
+		cl = append(cl, g.cloneCodeLines(field, field.Type, recv+"."+field.Name, clone+"."+field.Name)...)
+	}
+	cl = cl.Append("        return %s", clone)
+	cl = cl.Append("}")
+	return
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// modulePath is the path of the goaccessor module, which the go:generate
+// directives run.
+const modulePath = "github.com/yujiachen-y/goaccessor"
+
+// directive is a go:generate directive running goaccessor.
+type directive struct {
+	// pos is the position of the directive, like file.go:12.
+	pos string
+	// dir is the directory the directive runs in.
+	dir string
+	// args are the arguments passed to goaccessor.
+	args []string
+}
+
+// runDirectives runs the goaccessor go:generate directives of the packages
+// matching the patterns in dir. The packages are loaded once for all directives, the
+// directives referring to other directories or build configurations load
+// their packages on their own.
+func runDirectives(dir string, patterns []string, build buildConfig) error {
+	loaded, err := loadPackages(dir, patterns, build)
+	if err != nil {
+		return fmt.Errorf("loadPackages: %w", err)
+	}
+
+	pkgDirs := make([]string, 0, len(loaded))
+	for pkgDir := range loaded {
+		pkgDirs = append(pkgDirs, pkgDir)
+	}
+	sort.Strings(pkgDirs)
+
+	for _, pkgDir := range pkgDirs {
+		directives, err := findDirectives(loaded[pkgDir], build)
+		if err != nil {
+			return fmt.Errorf("findDirectives %s: %w", pkgDir, err)
+		}
+		for _, d := range directives {
+			if err := d.run(loaded, build); err != nil {
+				return fmt.Errorf("%s: %w", d.pos, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d directive) run(loaded map[string][]*packages.Package, build buildConfig) error {
+	debug.Printf("run directive %s: goaccessor %s\n", d.pos, strings.Join(d.args, " "))
+	cmd, err := parseCommand(d.args, io.Discard)
+	if err != nil {
+		return fmt.Errorf("invalid arguments %s", d.args)
+	}
+	if len(cmd.targets) == 0 {
+		return fmt.Errorf("no target specified in %s", d.args)
+	}
+	cmd.debug()
+
+	dir, err := cmd.dir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	var generators []*Generator
+	pkgs, ok := loaded[dir]
+	if ok && (reflect.DeepEqual(cmd.build, buildConfig{}) || reflect.DeepEqual(cmd.build, build)) {
+		generators, err = newGenerators(cmd.targets, dir, cmd.field, pkgs)
+	} else {
+		generators, err = NewGenerators(cmd.targets, dir, cmd.field, cmd.build)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create generators: %w", err)
+	}
+	if err := cmd.generate(generators); err != nil {
+		return err
+	}
+	return addGeneratedFiles(pkgs, generators, build)
+}
+
+// addGeneratedFiles adds the files written by the generators to pkgs, which
+// are loaded before the files are written, so the following directives can
+// check their names against the files. The files written for another build
+// configuration are left out unless they are included in the build of pkgs.
+func addGeneratedFiles(pkgs []*packages.Package, generators []*Generator, build buildConfig) error {
+	for _, g := range generators {
+		path, err := filepath.Abs(g.FilePath())
+		if err != nil {
+			return fmt.Errorf("filepath.Abs: %w", err)
+		}
+		match, err := build.matchFile(path)
+		if err != nil {
+			return fmt.Errorf("build.matchFile: %w", err)
+		}
+		if !match {
+			debug.Printf("skip %s, which isn't included in the build", path)
+			continue
+		}
+		for _, pkg := range pkgs {
+			if pkg.Name != g.Pkg || slices.ContainsFunc(pkg.Syntax, func(file *ast.File) bool {
+				return pkg.Fset.Position(file.Package).Filename == path
+			}) {
+				continue
+			}
+			file, err := parser.ParseFile(pkg.Fset, path, nil, parser.PackageClauseOnly)
+			if err != nil {
+				return fmt.Errorf("parser.ParseFile: %w", err)
+			}
+			pkg.Syntax = append(pkg.Syntax, file)
+		}
+	}
+	return nil
+}
+
+// findDirectives returns the goaccessor go:generate directives of pkgs, the
+// packages of the same directory, in the order of go generate.
+func findDirectives(pkgs []*packages.Package, build buildConfig) ([]directive, error) {
+	type file struct {
+		pkg  *packages.Package
+		file *ast.File
+		path string
+	}
+	var files []file
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			path := pkg.Fset.Position(f.Package).Filename
+			if !isGeneratedFile(path) {
+				files = append(files, file{pkg: pkg, file: f, path: path})
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	var directives []directive
+	for _, f := range files {
+		for _, group := range f.file.Comments {
+			for _, c := range group.List {
+				line, ok := strings.CutPrefix(c.Text, "//go:generate ")
+				if !ok {
+					continue
+				}
+				pos := f.pkg.Fset.Position(c.Slash)
+				words, err := splitDirective(line, directiveEnv(f.pkg, pos.Filename, pos.Line, build))
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", pos.Filename, pos.Line, err)
+				}
+				dir := filepath.Dir(pos.Filename)
+				args, ok := goaccessorArgs(words, dir)
+				if !ok {
+					debug.Printf("skip directive %s:%d: %s\n", pos.Filename, pos.Line, line)
+					continue
+				}
+				directives = append(directives, directive{
+					pos:  fmt.Sprintf("%s:%d", pos.Filename, pos.Line),
+					dir:  dir,
+					args: args,
+				})
+			}
+		}
+	}
+	return directives, nil
+}
+
+// directiveEnv returns the environment variables go generate sets for the
+// directive at the line of the file.
+func directiveEnv(pkg *packages.Package, filename string, line int, build buildConfig) func(string) string {
+	return func(key string) string {
+		switch key {
+		case "GOFILE":
+			return filepath.Base(filename)
+		case "GOLINE":
+			return strconv.Itoa(line)
+		case "GOPACKAGE":
+			return pkg.Name
+		case "GOOS":
+			if build.goos != "" {
+				return build.goos
+			}
+			if goos := os.Getenv("GOOS"); goos != "" {
+				return goos
+			}
+			return runtime.GOOS
+		case "GOARCH":
+			if build.goarch != "" {
+				return build.goarch
+			}
+			if goarch := os.Getenv("GOARCH"); goarch != "" {
+				return goarch
+			}
+			return runtime.GOARCH
+		case "DOLLAR":
+			return "$"
+		}
+		return os.Getenv(key)
+	}
+}
+
+// splitDirective splits the command line of a go:generate directive into
+// words the same way go generate does: words are separated by spaces, quoted
+// strings are single words, and environment variables are expanded.
+func splitDirective(line string, env func(string) string) ([]string, error) {
+	var words []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return words, nil
+		}
+		if line[0] == '"' {
+			end := 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted string in %s", line)
+			}
+			word, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("strconv.Unquote(%s): %w", line[:end+1], err)
+			}
+			words = append(words, os.Expand(word, env))
+			line = line[end+1:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		words = append(words, os.Expand(line[:end], env))
+		line = line[end:]
+	}
+}
+
+// goaccessorArgs returns the arguments passed to goaccessor by the command
+// words of a directive in dir. The command runs goaccessor if it is the
+// goaccessor binary, or go run of the goaccessor module.
+func goaccessorArgs(words []string, dir string) ([]string, bool) {
+	if len(words) == 0 {
+		return nil, false
+	}
+	if filepath.Base(words[0]) == "goaccessor" {
+		return words[1:], true
+	}
+	if len(words) < 3 || words[0] != "go" || words[1] != "run" {
+		return nil, false
+	}
+
+	// skip the build flags of go run, which are like -mod=mod
+	i := 2
+	for i < len(words) && strings.HasPrefix(words[i], "-") {
+		i++
+	}
+	if i == len(words) {
+		return nil, false
+	}
+	pkg, _, _ := strings.Cut(words[i], "@")
+	if pkg == modulePath || isModuleRoot(filepath.Join(dir, pkg)) {
+		return words[i+1:], true
+	}
+	return nil, false
+}
+
+// isModuleRoot reports whether dir is the root of the goaccessor module.
+func isModuleRoot(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return false
+	}
+	return modfile.ModulePath(data) == modulePath
+}
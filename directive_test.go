@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitDirective(t *testing.T) {
+	env := func(key string) string {
+		return map[string]string{"GOFILE": "file.go", "DOLLAR": "$"}[key]
+	}
+	type testCase struct {
+		input  string
+		output []string
+		err    bool
+	}
+	for _, tc := range []testCase{
+		{
+			input:  "goaccessor -t Book -a",
+			output: []string{"goaccessor", "-t", "Book", "-a"},
+		},
+		{
+			input:  "  go run ../../.\t-t Book  $GOFILE ",
+			output: []string{"go", "run", "../../.", "-t", "Book", "file.go"},
+		},
+		{
+			input:  `goaccessor -p "Best Selling" -i "a\"b" ${DOLLAR}x`,
+			output: []string{"goaccessor", "-p", "Best Selling", "-i", `a"b`, "$x"},
+		},
+		{
+			input: `goaccessor -p "unterminated`,
+			err:   true,
+		},
+	} {
+		got, err := splitDirective(tc.input, env)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error for %s, got %q", tc.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.input, err.Error())
+		} else if !reflect.DeepEqual(got, tc.output) {
+			t.Errorf("got %q, expected %q", got, tc.output)
+		}
+	}
+}
+
+func TestGoaccessorArgs(t *testing.T) {
+	root, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatalf("filepath.Abs: %s", err.Error())
+	}
+	dir := filepath.Join(root, "test", "vartest")
+
+	type testCase struct {
+		words []string
+		args  []string
+		ok    bool
+	}
+	for _, tc := range []testCase{
+		{
+			words: []string{"goaccessor", "-t", "a"},
+			args:  []string{"-t", "a"},
+			ok:    true,
+		},
+		{
+			words: []string{"/go/bin/goaccessor", "-t", "a"},
+			args:  []string{"-t", "a"},
+			ok:    true,
+		},
+		{
+			words: []string{"go", "run", "github.com/yujiachen-y/goaccessor@latest", "-t", "a"},
+			args:  []string{"-t", "a"},
+			ok:    true,
+		},
+		{
+			words: []string{"go", "run", "-mod=mod", "github.com/yujiachen-y/goaccessor", "-t", "a"},
+			args:  []string{"-t", "a"},
+			ok:    true,
+		},
+		{
+			words: []string{"go", "run", "../../.", "-t", "a"},
+			args:  []string{"-t", "a"},
+			ok:    true,
+		},
+		{
+			words: []string{"go", "run", "golang.org/x/tools/cmd/stringer", "-type", "a"},
+		},
+		{
+			words: []string{"go", "run", "../utils", "-t", "a"},
+		},
+		{
+			words: []string{"stringer", "-type", "a"},
+		},
+	} {
+		args, ok := goaccessorArgs(tc.words, dir)
+		if ok != tc.ok || !reflect.DeepEqual(args, tc.args) {
+			t.Errorf("got %q %t for %q, expected %q %t", args, ok, tc.words, tc.args, tc.ok)
+		}
+	}
+}
+
+func TestRunDirectives(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"a.go": "package a\n\n//go:generate goaccessor -t Book -g\ntype Book struct {\n\ttitle string\n}\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "b"), 0o755); err != nil {
+		t.Fatalf("os.Mkdir: %s", err.Error())
+	}
+	src := "package b\n\n//go:generate stringer -type Kind\ntype Kind int\n\n//go:generate goaccessor -t name -s $GOFILE\nvar name string\n"
+	if err := os.WriteFile(filepath.Join(dir, "b", "b.go"), []byte(src), 0o644); err != nil {
+		t.Fatalf("os.WriteFile: %s", err.Error())
+	}
+
+	if err := runDirectives(dir, []string{"./..."}, buildConfig{}); err != nil {
+		t.Fatalf("runDirectives: %s", err.Error())
+	}
+	for path, expected := range map[string]string{
+		"abook_goaccessor.go":   `// Code generated by "goaccessor -t Book -g". DO NOT EDIT.`,
+		"b/bname_goaccessor.go": `// Code generated by "goaccessor -t name -s b.go". DO NOT EDIT.`,
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Errorf("os.ReadFile %s: %s", path, err.Error())
+		} else if !strings.HasPrefix(string(content), expected) {
+			t.Errorf("expected %s to start with %s, got:\n%s", path, expected, content)
+		}
+	}
+}
+
+func TestRunDirectivesOtherBuild(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"gen.go":     "package a\n\n//go:generate goaccessor -t v -g -os plan9\n//go:generate goaccessor -t v -g\n",
+		"v_plan9.go": "package a\n\nvar v = 1\n",
+		"v_other.go": "//go:build !plan9\n\npackage a\n\nvar v = 2\n",
+	})
+
+	if err := runDirectives(dir, []string{"."}, buildConfig{goos: "linux"}); err != nil {
+		t.Fatalf("runDirectives: %s", err.Error())
+	}
+	// the accessors for plan9 don't conflict with the ones for linux
+	for _, path := range []string{"v_plan9v_goaccessor.go", "v_otherv_goaccessor.go"} {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Errorf("os.ReadFile %s: %s", path, err.Error())
+		} else if !strings.Contains(string(content), "func GetV() int") {
+			t.Errorf("expected GetV in %s, got:\n%s", path, content)
+		}
+	}
+}
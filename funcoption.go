@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// getFunctionalOptionCodeLines returns the functional options of the struct
+// type, like the ones of the generator itself: a BookOption type, a WithX
+// function setting each selected field, and a NewBook constructor applying the
+// options to the default value. The default value is returned by the function
+// named like defaultBook if it exists, or has the default values of the
+// fields. The option type and the constructor are skipped together if the
+// option type already exists.
+func (g *Generator) getFunctionalOptionCodeLines() (cl codeLines, err error) {
+	name := g.Name + "Option"
+	optionName, err := g.resolveTypeName(name)
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if optionName == "" {
+		return cl.Append("// %s already exists", name), nil
+	}
+
+	var fields, defaults []Field
+	for _, field := range g.Fields {
+		if field.Promoted || field.LockKind != "" {
+			continue
+		}
+		if field.Config != nil && field.Config.Default != "" {
+			defaults = append(defaults, field)
+		}
+		if g.isFieldSelected(field) {
+			fields = append(fields, field)
+		}
+	}
+	if g.DefaultFunc != "" && len(defaults) > 0 {
+		return nil, fmt.Errorf("'%s' has both %s and the default values of fields, only one of them can be used", g.Name, g.DefaultFunc)
+	}
+
+	optionType, typeParams := g.instantiate(optionName), g.getTypeParamList()
+	recv, v := g.getReceiverName(), g.newValueName()
+	cl = cl.Append("// %s configures %s.", optionName, g.Name)
+	cl = cl.Append("type %s%s func(*%s)", optionName, typeParams, g.getReceiverType())
+
+	for _, field := range fields {
+		name := g.modifierName("with", field)
+		funcName, err := g.resolve(name, "function")
+		if err != nil {
+			return nil, err
+		}
+		cl = cl.Append("")
+		if funcName == "" {
+			cl = cl.Append("// %s already exists", name)
+			continue
+		}
+		cl = cl.Append("func %s%s(%s %s) %s {", funcName, typeParams, v, field.Type, optionType)
+		value := v
+		if g.cloned(field) {
+			var cloneCodeLines codeLines
+			cloneCodeLines, value = g.setterCloneCodeLines(field, field.Type)
+			cl = append(cl, cloneCodeLines...)
+		}
+		cl = cl.Append("        return func(%s *%s) {", recv, g.getReceiverType())
+		cl = cl.Append("                %s.%s = %s", recv, field.Name, value)
+		cl = cl.Append("        }")
+		cl = cl.Append("}")
+	}
+
+	constructor := "New" + upper(g.Name)
+	if !token.IsExported(g.Name) {
+		constructor = lower(constructor)
+	}
+	constructorName, err := g.resolve(constructor, "function")
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if constructorName == "" {
+		return cl.Append("// %s already exists", constructor), nil
+	}
+	cl = cl.Append("// %s returns a new %s configured by the options.", constructorName, g.Name)
+	cl = cl.Append("func %s%s(opts ...%s) *%s {", constructorName, typeParams, optionType, g.getReceiverType())
+	switch {
+	case g.DefaultFunc != "" && g.DefaultPointer:
+		cl = cl.Append("        %s := %s()", recv, g.instantiate(g.DefaultFunc))
+	case g.DefaultFunc != "":
+		cl = cl.Append("        %s := %s()", v, g.instantiate(g.DefaultFunc))
+		cl = cl.Append("        %s := &%s", recv, v)
+	case len(defaults) > 0:
+		cl = cl.Append("        %s := &%s{", recv, g.getReceiverType())
+		for _, field := range defaults {
+			cl = cl.Append("                %s: %s,", field.Name, field.Config.Default)
+		}
+		cl = cl.Append("        }")
+	default:
+		cl = cl.Append("        %s := &%s{}", recv, g.getReceiverType())
+	}
+	cl = cl.Append("        for _, opt := range opts {")
+	cl = cl.Append("                opt(%s)", recv)
+	cl = cl.Append("        }")
+	cl = cl.Append("        return %s", recv)
+	cl = cl.Append("}")
+	return
+}
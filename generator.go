@@ -1,12 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 type options struct {
@@ -16,8 +26,69 @@ type options struct {
 	prefix     string
 	includes   map[string]struct{}
 	excludes   map[string]struct{}
+	embed      bool
+	promote    bool
+	conflict   ConflictPolicy
+	// sync guards the accessors with the lock, a sync.Mutex or sync.RWMutex
+	// which is found in the struct type or declared for the variable if it
+	// isn't specified.
+	sync bool
+	lock string
+	// chain makes the setters of the struct type return the receiver.
+	chain bool
+	// wither generates WithX for each field of the struct type, which returns
+	// a copy of the value receiver with the field set.
+	wither bool
+	// constructor generates a constructor of the struct type, and
+	// constructorFields generates another one taking a struct of the fields,
+	// see constructor.go.
+	constructor, constructorFields bool
+	// functionalOptions generates the functional options of the struct type,
+	// see funcoption.go.
+	functionalOptions bool
+	// builder generates a builder of the struct type, see builder.go.
+	builder bool
+	// copy makes the accessors clone the slices, maps, and arrays of pointers,
+	// and generates Clone for the struct type, see copy.go.
+	copy bool
+	// validate makes the setters check the constraints in the validate tags of
+	// the fields and return errors, and generates Validate for the struct
+	// type, see validate.go.
+	validate bool
+	// nilSafe makes the getters return the zero values if the receivers or the
+	// variables are nil pointers.
+	nilSafe bool
+	// atomic stores the variable in a sync/atomic type, see atomic.go.
+	atomic bool
+	// update generates UpdateX in atomic mode, which takes a context if
+	// updateContext is set and gives up after updateRetries attempts if it's
+	// positive.
+	update        bool
+	updateContext bool
+	updateRetries int
+	// interfaces are declared with the accessors of a struct type, in the
+	// package in interfaceDir if it isn't empty.
+	interfaces   []Interface
+	interfaceDir string
+	// args are the arguments goaccessor is invoked with, which are recorded
+	// in the generated files.
+	args []string
 }
 
+// ConflictPolicy decides what to do with a generated name which is declared
+// already.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip skips the name, leaving a comment in the generated file.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictFail fails the generation, reporting the conflicting declaration.
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictRename appends the smallest number from 2 which makes the name
+	// unique.
+	ConflictRename ConflictPolicy = "rename"
+)
+
 type optionsFn func(*options)
 
 func WithGetter(v bool) optionsFn {
@@ -72,21 +143,194 @@ func WithExcludes(excludes []string) optionsFn {
 	}
 }
 
+func WithEmbed(v bool) optionsFn {
+	return func(o *options) {
+		o.embed = v
+	}
+}
+
+func WithPromote(v bool) optionsFn {
+	return func(o *options) {
+		o.promote = v
+	}
+}
+
+func WithConflict(p ConflictPolicy) optionsFn {
+	return func(o *options) {
+		o.conflict = p
+	}
+}
+
+func WithSync(v bool) optionsFn {
+	return func(o *options) {
+		o.sync = v
+	}
+}
+
+func WithLock(name string) optionsFn {
+	return func(o *options) {
+		o.lock = name
+	}
+}
+
+func WithChain(v bool) optionsFn {
+	return func(o *options) {
+		o.chain = v
+	}
+}
+
+func WithWither(v bool) optionsFn {
+	return func(o *options) {
+		o.wither = v
+	}
+}
+
+func WithConstructor(v bool) optionsFn {
+	return func(o *options) {
+		o.constructor = v
+	}
+}
+
+func WithConstructorFields(v bool) optionsFn {
+	return func(o *options) {
+		o.constructorFields = v
+	}
+}
+
+func WithFunctionalOptions(v bool) optionsFn {
+	return func(o *options) {
+		o.functionalOptions = v
+	}
+}
+
+func WithBuilder(v bool) optionsFn {
+	return func(o *options) {
+		o.builder = v
+	}
+}
+
+func WithCopy(v bool) optionsFn {
+	return func(o *options) {
+		o.copy = v
+	}
+}
+
+func WithValidate(v bool) optionsFn {
+	return func(o *options) {
+		o.validate = v
+	}
+}
+
+func WithNilSafe(v bool) optionsFn {
+	return func(o *options) {
+		o.nilSafe = v
+	}
+}
+
+func WithAtomic(v bool) optionsFn {
+	return func(o *options) {
+		o.atomic = v
+	}
+}
+
+func WithUpdate(v bool) optionsFn {
+	return func(o *options) {
+		o.update = v
+	}
+}
+
+func WithUpdateContext(v bool) optionsFn {
+	return func(o *options) {
+		o.updateContext = v
+	}
+}
+
+func WithUpdateRetries(n int) optionsFn {
+	return func(o *options) {
+		o.updateRetries = n
+	}
+}
+
+func WithInterfaces(interfaces []Interface) optionsFn {
+	return func(o *options) {
+		o.interfaces = interfaces
+	}
+}
+
+func WithInterfaceDir(dir string) optionsFn {
+	return func(o *options) {
+		o.interfaceDir = dir
+	}
+}
+
+func WithArgs(args []string) optionsFn {
+	return func(o *options) {
+		o.args = args
+	}
+}
+
 type Generator struct {
-	Name          string
-	Dir           string
-	Pkg           string
-	Type          string
-	TypeParams    []string
-	TypeArguments []string
-	ReceiverName  string
-	Fields        []Field
-	Methods       map[string]struct{}
-	FileName      string
+	Name string
+	Dir  string
+	Pkg  string
+	// PkgPath is the import path of the package of the target.
+	PkgPath    string
+	Type       string
+	TypeParams []string
+	// TypeConstraints are the constraints of the type parameters.
+	TypeConstraints []string
+	TypeArguments   []string
+	ReceiverName    string
+	Fields          []Field
+	// Methods maps the methods of the target type to where they are declared.
+	Methods  map[string]string
+	FileName string
+	// Test reports whether the target is declared in a test file, whose
+	// accessors are generated into a test file as well.
+	Test bool
+	// BuildConstraint is the build constraint of the file declaring the target,
+	// which the generated file carries as well.
+	BuildConstraint string
+	// Declared maps the names of the package scope, including the functions in
+	// the files generated for other targets, to where they are declared.
+	Declared map[string]string
+	// DefaultFunc is the function returning the default value of the struct
+	// type, and DefaultPointer reports whether it returns a pointer.
+	DefaultFunc    string
+	DefaultPointer bool
+	// Hooks maps the names of the methods of the struct type to the hooks
+	// they are, which are called by the setters, see Hook.
+	Hooks map[string]Hook
+	// Pointer reports whether the variable is a pointer, whose fields are
+	// accessed through it.
+	Pointer bool
+	// AtomicType is the sync/atomic type storing the values of the variable in
+	// atomic mode, and AtomicValueType is the type they are converted to for
+	// it, which is empty if they aren't converted.
+	AtomicType, AtomicValueType string
+	// Comparable reports whether the values of the variable are comparable.
+	Comparable bool
+	// Uses are the positions where the variable is used in the package apart
+	// from the generated files, which can't see its values in atomic mode.
+	Uses []string
+	// Locks maps the package level variables of sync.Mutex and sync.RWMutex
+	// to their types, which can guard the variables.
+	Locks map[string]string
+	// Generated is shared by the generators of a package, and maps the
+	// functions and types they have generated to their targets.
+	Generated     map[string]string
 	GeneratorType GeneratorType
-	Imports       []string
+	Imports       []Import
 
 	opts *options
+	// accessorMethods are the accessors generated for a struct type, which
+	// are declared by the interfaces.
+	accessorMethods []accessorMethod
+	// lock guards the accessors in sync mode, see resolveLock.
+	lock *lock
+	// patterns maps the fields to the variables of their compiled patterns in
+	// validate mode, which are declared once.
+	patterns map[string]string
 }
 
 type GeneratorType int
@@ -101,67 +345,115 @@ const (
 
 type Field struct {
 	Name, Type string
+	// Zero is the expression of the zero value of the field.
+	Zero string
+	// Embedded reports whether the field is an embedded field, whose name is
+	// the name of its type.
+	Embedded bool
+	// Promoted reports whether the field is promoted from an embedded struct,
+	// and Indirect reports whether it's promoted through an embedded pointer,
+	// whose struct is shared by the copies of the target.
+	Promoted, Indirect bool
+	// Pointers are the selectors of the embedded pointers on the path to the
+	// promoted field, which are checked by the getters in nil-safe mode.
+	Pointers []string
+	// CopyKind is "slice", "map", or "array" if the field is one of them, or ""
+	// otherwise, which decides how it's cloned in copy mode.
+	CopyKind string
+	// ElemPointer reports whether the elements of the field are pointers, or
+	// the field itself if it has no elements.
+	ElemPointer bool
+	// LockKind is "Mutex" or "RWMutex" if the field is a sync.Mutex or
+	// sync.RWMutex, or a pointer to them, which can guard the other fields.
+	LockKind string
+	// Config is the configuration of the field, nil if the field has none.
+	Config *FieldConfig
+	// ValidateTag is the value of the validate tag of the field, ValidateKind
+	// is how its values are checked, see validationKind, and ValidateBasic is
+	// the underlying basic type of the numbers, see validationBasic.
+	ValidateTag, ValidateKind, ValidateBasic string
+	// Validations are the constraints in the validate tag of the field, which
+	// are parsed in validate mode.
+	Validations []Validation
 }
 
-func (g *Generator) InspectImports(unnamedImports []string, namedImports map[string]string) error {
-	seenPkgs := make(map[string]struct{})
-	if pkgName := getPackageNameFromType(g.Type); pkgName != "" {
-		if err := g.inspectImport(pkgName, unnamedImports, namedImports, seenPkgs); err != nil {
-			return fmt.Errorf("g.inspectImport %s %v %v: %w", pkgName, unnamedImports, namedImports, err)
-		}
-	}
+// FieldConfig configures the accessors of a field, through either its struct
+// tag like `accessor:"get,name=ID"` or its directives like
+// //goaccessor:getter name=ID.
+type FieldConfig struct {
+	// Skip reports whether the field is skipped.
+	Skip bool
+	// Getter and Setter report whether the getter and setter are generated,
+	// they override the options if either of them is set.
+	Getter, Setter bool
+	// GetterName and SetterName are used in the names of the getter and the
+	// setter instead of the field name.
+	GetterName, SetterName string
+	// UnexportedGetter and UnexportedSetter report whether the getter and the
+	// setter are unexported.
+	UnexportedGetter, UnexportedSetter bool
+	// Lock is the lock field guarding the accessors instead of the one of the
+	// options.
+	Lock string
+	// Clone reports whether the values of the field are cloned by their Clone
+	// methods in copy mode.
+	Clone bool
+	// Required reports whether the field must be set by the builder.
+	Required bool
+	// Optional reports whether the field is left out of the parameters of the
+	// constructor.
+	Optional bool
+	// Default is the expression of the default value of the field, which is
+	// set by the constructors.
+	Default string
+}
 
-	for _, typeArg := range g.TypeArguments {
-		pkgName := getPackageNameFromType(typeArg)
-		if pkgName == "" {
-			continue
-		}
-		if err := g.inspectImport(pkgName, unnamedImports, namedImports, seenPkgs); err != nil {
-			return fmt.Errorf("g.inspectImport %s %v %v: %w", pkgName, unnamedImports, namedImports, err)
-		}
-	}
+type Import struct {
+	Name, Path string
+}
 
-	for _, field := range g.Fields {
-		pkgName := getPackageNameFromType(field.Type)
-		if pkgName == "" {
-			continue
-		}
-		if err := g.inspectImport(pkgName, unnamedImports, namedImports, seenPkgs); err != nil {
-			return fmt.Errorf("g.inspectImport %s %v %v: %w", pkgName, unnamedImports, namedImports, err)
-		}
+// specName returns the name used in the import spec, it is omitted when the
+// package name is the same as the last element of the path.
+func (i Import) specName() string {
+	if i.Name == path.Base(i.Path) {
+		return ""
 	}
-	return nil
+	return i.Name
 }
 
-func (g *Generator) inspectImport(pkgName string, unnamedImports []string, namedImports map[string]string, seenPkgs map[string]struct{}) error {
-	if _, ok := seenPkgs[pkgName]; ok {
-		return nil
+func (i Import) String() string {
+	if name := i.specName(); name != "" {
+		return name + " " + strconv.Quote(i.Path)
 	}
-	seenPkgs[pkgName] = struct{}{}
+	return strconv.Quote(i.Path)
+}
 
-	if ipt, ok := namedImports[pkgName]; ok {
-		g.Imports = append(g.Imports, ipt)
-		return nil
-	}
+// AddImport records the package the generated code may refer to, and returns
+// the name to refer to it. The package is renamed if its name is taken by
+// another import or declared reports true for it.
+func (g *Generator) AddImport(name, pkgPath string, declared func(name string) bool) string {
+	var alias string
+	g.Imports, alias = addImport(g.Imports, name, pkgPath, declared)
+	return alias
+}
 
-	for i, ctn := 0, true; ctn; i++ {
-		ctn = false
-		for _, ipt := range unnamedImports {
-			trimmedIPT := strings.Trim(ipt, "\"")
-			names := strings.Split(trimmedIPT, "/")
-			p := len(names) - 1 - i
-			if p < 0 {
-				continue
-			}
+func addImport(imports []Import, name, pkgPath string, declared func(name string) bool) ([]Import, string) {
+	taken := make(map[string]struct{}, len(imports))
+	for _, ipt := range imports {
+		if ipt.Path == pkgPath {
+			return imports, ipt.Name
+		}
+		taken[ipt.Name] = struct{}{}
+	}
 
-			ctn = true
-			if pkgName == names[p] {
-				g.Imports = append(g.Imports, ipt)
-				return nil
-			}
+	alias := name
+	for i := 2; ; i++ {
+		if _, ok := taken[alias]; !ok && !declared(alias) {
+			break
 		}
+		alias = name + strconv.Itoa(i)
 	}
-	return fmt.Errorf("cannot find package %s", pkgName)
+	return append(imports, Import{Name: alias, Path: pkgPath}), alias
 }
 
 func (g *Generator) Generate(optsFn ...optionsFn) error {
@@ -169,19 +461,51 @@ func (g *Generator) Generate(optsFn ...optionsFn) error {
 	for _, o := range optsFn {
 		o(g.opts)
 	}
+	g.accessorMethods = nil
+	g.patterns = nil
 
 	debug.Printf("Generator.Name %s", g.Name)
 	debug.Printf("Generator.Dir %s", g.Dir)
 	debug.Printf("Generator.Pkg %s", g.Pkg)
+	debug.Printf("Generator.PkgPath %s", g.PkgPath)
 	debug.Printf("Generator.Type %s", g.Type)
 	debug.Printf("Generator.TypeParams %s", g.TypeParams)
 	debug.Printf("Generator.TypeArguments %s", g.TypeArguments)
 	debug.Printf("Generator.ReceiverName %s", g.ReceiverName)
-	debug.Printf("Generator.Fields %s", g.Fields)
+	debug.Printf("Generator.Fields %v", g.Fields)
 	debug.Printf("Generator.Methods %s", g.Methods)
 	debug.Printf("Generator.FileName %s", g.FileName)
+	debug.Printf("Generator.Test %t", g.Test)
+	debug.Printf("Generator.BuildConstraint %s", g.BuildConstraint)
+	debug.Printf("Generator.Declared %s", g.Declared)
+	debug.Printf("Generator.Hooks %v", g.Hooks)
+	debug.Printf("Generator.Pointer %t", g.Pointer)
+	debug.Printf("Generator.AtomicType %s", g.AtomicType)
+	debug.Printf("Generator.AtomicValueType %s", g.AtomicValueType)
+	debug.Printf("Generator.Comparable %t", g.Comparable)
+	debug.Printf("Generator.Uses %s", g.Uses)
+	debug.Printf("Generator.Locks %s", g.Locks)
 	debug.Printf("Generator.GeneratorType %s", g.GeneratorType)
-	debug.Printf("Generator.Imports %s", g.Imports)
+	debug.Printf("Generator.Imports %v", g.Imports)
+
+	if (g.opts.atomic || g.opts.update) && g.GeneratorType != GeneratorTypeVariable {
+		return fmt.Errorf("can't store '%s' atomically, which isn't a variable", g.Name)
+	}
+	if g.opts.validate && g.opts.chain {
+		return fmt.Errorf("can't chain the setters of '%s', which return errors in validate mode", g.Name)
+	}
+	if len(g.opts.interfaces) > 0 && g.GeneratorType != GeneratorTypeStructure {
+		return fmt.Errorf("can't declare interfaces for '%s', which isn't a struct type", g.Name)
+	}
+	if err := g.checkStructOptions(); err != nil {
+		return err
+	}
+
+	if g.opts.validate {
+		if err := g.parseValidations(); err != nil {
+			return err
+		}
+	}
 
 	// TODO replace the method route by interface.
 	var err error
@@ -202,19 +526,78 @@ func (g *Generator) Generate(optsFn ...optionsFn) error {
 	return nil
 }
 
+// checkStructOptions reports an error if the options only applicable to the
+// struct types are used for other targets, which would be ignored otherwise.
+func (g *Generator) checkStructOptions() error {
+	if g.GeneratorType == GeneratorTypeStructure {
+		return nil
+	}
+	type option struct {
+		enabled bool
+		what    string
+	}
+	options := []option{
+		{g.opts.chain, "chain the setters"},
+		{g.opts.wither, "generate withers"},
+		{g.opts.builder, "generate the builder"},
+		{g.opts.functionalOptions, "generate the functional options"},
+		{g.opts.constructor, "generate the constructor"},
+	}
+	if g.GeneratorType == GeneratorTypeVariable {
+		// the fields of the variables are cloned and validated in field mode
+		options = append(options, option{g.opts.copy, "clone the fields"}, option{g.opts.validate, "validate the fields"})
+	}
+	for _, o := range options {
+		if o.enabled {
+			return fmt.Errorf("can't %s of '%s', which isn't a struct type", o.what, g.Name)
+		}
+	}
+	return nil
+}
+
 func (g *Generator) WriteVarAccessor() error {
-	cl := append(g.getPackageCodeLines(), g.getVarCodeLines()...)
-	return g.writeFile(cl)
+	getVarCodeLines := g.getVarCodeLines
+	if g.opts.atomic || g.opts.update {
+		getVarCodeLines = g.getAtomicCodeLines
+	}
+	varCodeLines, err := getVarCodeLines()
+	if err != nil {
+		return err
+	}
+	cl := append(g.getPackageCodeLines(), varCodeLines...)
+	return g.writeFile(g.FilePath(), g.Imports, cl)
 }
 
 func (g *Generator) WriteStructAccessor() error {
-	cl := append(g.getPackageCodeLines(), g.getStructCodeLines()...)
-	return g.writeFile(cl)
+	structCodeLines, err := g.getStructCodeLines()
+	if err != nil {
+		return err
+	}
+	cl := append(g.getPackageCodeLines(), structCodeLines...)
+
+	if len(g.opts.interfaces) > 0 {
+		if g.opts.interfaceDir != "" {
+			if err := g.writeInterfaceFile(); err != nil {
+				return fmt.Errorf("g.writeInterfaceFile: %w", err)
+			}
+		} else {
+			interfaceCodeLines, err := g.getInterfaceCodeLines()
+			if err != nil {
+				return err
+			}
+			cl = append(cl, interfaceCodeLines...)
+		}
+	}
+	return g.writeFile(g.FilePath(), g.Imports, cl)
 }
 
 func (g *Generator) WriteFieldAccessor() error {
-	cl := append(g.getPackageCodeLines(), g.getFieldCodeLines()...)
-	return g.writeFile(cl)
+	fieldCodeLines, err := g.getFieldCodeLines()
+	if err != nil {
+		return err
+	}
+	cl := append(g.getPackageCodeLines(), fieldCodeLines...)
+	return g.writeFile(g.FilePath(), g.Imports, cl)
 }
 
 type codeLines []struct {
@@ -229,20 +612,30 @@ func (c codeLines) Append(format string, a ...interface{}) codeLines {
 	}{format, a})
 }
 
-func (g *Generator) getPackageCodeLines() (cl codeLines) {
-	cl = cl.Append("// Code generated by \"goaccessor %s\". DO NOT EDIT.", strings.Join(os.Args[1:], " "))
+func (g *Generator) getPackageCodeLines() codeLines {
+	return g.getHeaderCodeLines(g.Pkg, g.Imports)
+}
+
+// getHeaderCodeLines returns the header of a generated file in the package,
+// which may be other than the package of the target.
+func (g *Generator) getHeaderCodeLines(pkg string, imports []Import) (cl codeLines) {
+	cl = cl.Append("// Code generated by \"goaccessor %s\". DO NOT EDIT.", strings.Join(g.opts.args, " "))
 	cl = cl.Append("")
-	cl = cl.Append("package %s", g.Pkg)
+	if g.BuildConstraint != "" {
+		cl = cl.Append("//go:build %s", g.BuildConstraint)
+		cl = cl.Append("")
+	}
+	cl = cl.Append("package %s", pkg)
 
-	if len(g.Imports) == 1 {
+	if len(imports) == 1 {
 		cl = cl.Append("")
-		cl = cl.Append("import %s", g.Imports[0])
+		cl = cl.Append("import %s", imports[0])
 		cl = cl.Append("")
 	}
-	if len(g.Imports) > 1 {
+	if len(imports) > 1 {
 		cl = cl.Append("")
 		cl = cl.Append("import (")
-		for _, ipt := range g.Imports {
+		for _, ipt := range imports {
 			cl = cl.Append("        %s", ipt)
 		}
 		cl = cl.Append(")")
@@ -252,112 +645,536 @@ func (g *Generator) getPackageCodeLines() (cl codeLines) {
 	return
 }
 
-func (g *Generator) getVarCodeLines() (cl codeLines) {
-	getMethodName := concat(g.GetPrefix(), g.opts.prefix, g.Name)
-	if g.opts.getter && getMethodName != g.Name {
-		cl = cl.Append("")
-		cl = cl.Append("func %s() %s {", getMethodName, g.Type)
-		cl = cl.Append("        return %s", g.Name)
-		cl = cl.Append("}")
-	} else if g.opts.getter {
+func (g *Generator) getVarCodeLines() (cl codeLines, err error) {
+	cl, err = g.resolveLock()
+	if err != nil {
+		return nil, err
+	}
+
+	if g.opts.getter {
+		name := concat(g.GetPrefix(), g.opts.prefix, g.Name)
+		getMethodName, err := g.resolveName(name)
+		if err != nil {
+			return nil, err
+		}
 		cl = cl.Append("")
-		cl = cl.Append("// %s already exists", getMethodName)
+		if getMethodName != "" {
+			cl = cl.Append("func %s() %s {", getMethodName, g.Type)
+			cl = append(cl, g.lock.codeLines(false)...)
+			cl = cl.Append("        return %s", g.Name)
+			cl = cl.Append("}")
+		} else {
+			cl = cl.Append("// %s already exists", name)
+		}
 	}
 
 	if g.opts.setter {
+		name := concat("set", g.opts.prefix, g.Name)
+		setMethodName, err := g.resolveName(name)
+		if err != nil {
+			return nil, err
+		}
 		cl = cl.Append("")
-		cl = cl.Append("func %s(%s %s) {", concat("set", g.opts.prefix, g.Name), g.newValueName(), g.Type)
-		cl = cl.Append("        %s = %s", g.Name, g.newValueName())
-		cl = cl.Append("}")
+		if setMethodName != "" {
+			cl = cl.Append("func %s(%s %s) {", setMethodName, g.newValueName(), g.Type)
+			cl = append(cl, g.lock.codeLines(true)...)
+			cl = cl.Append("        %s = %s", g.Name, g.newValueName())
+			cl = cl.Append("}")
+		} else {
+			cl = cl.Append("// %s already exists", name)
+		}
 	}
 	return
 }
 
-func (g *Generator) getStructCodeLines() (cl codeLines) {
+func (g *Generator) getStructCodeLines() (cl codeLines, err error) {
+	cl, err = g.resolveLock()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, field := range g.Fields {
+		if !g.isFieldSelected(field) {
+			continue
+		}
 		fieldName, fieldType := field.Name, field.Type
-		if includes := g.opts.includes; len(includes) > 0 {
-			if _, ok := includes[fieldName]; !ok {
-				continue
-			}
+		l, err := g.fieldLock(field, g.getReceiverName())
+		if err != nil {
+			return nil, err
 		}
 
-		if excludes := g.opts.excludes; len(excludes) > 0 {
-			if _, ok := excludes[fieldName]; ok {
-				continue
+		getter, setter := g.accessors(field)
+		if getter {
+			name := g.getterName(field)
+			getMethodName, err := g.resolveName(name)
+			if err != nil {
+				return nil, err
+			}
+			cl = cl.Append("")
+			if getMethodName != "" {
+				cl = cl.Append("func (%s *%s) %s() %s {", g.getReceiverName(), g.getReceiverType(), getMethodName, fieldType)
+				if g.opts.nilSafe {
+					cl = append(cl, nilCheckCodeLines(g.getReceiverName(), field.Zero)...)
+				}
+				cl = append(cl, l.codeLines(false)...)
+				if g.opts.nilSafe {
+					for _, pointer := range field.Pointers {
+						cl = append(cl, nilCheckCodeLines(g.getReceiverName()+"."+pointer, field.Zero)...)
+					}
+				}
+				if g.cloned(field) {
+					cl = append(cl, g.getterCloneCodeLines(field, fieldType, g.getReceiverName()+"."+fieldName)...)
+				} else {
+					cl = cl.Append("        return %s.%s", g.getReceiverName(), fieldName)
+				}
+				cl = cl.Append("}")
+				g.accessorMethods = append(g.accessorMethods, accessorMethod{name: getMethodName, typ: fieldType})
+			} else {
+				cl = cl.Append("// %s already exists", name)
 			}
 		}
 
-		getMethodName := concat(g.GetPrefix(), g.opts.prefix, fieldName)
-		if g.opts.getter && !g.IsNameExist(getMethodName) {
-			cl = cl.Append("")
-			cl = cl.Append("func (%s *%s) %s() %s {", g.getReceiverName(), g.getReceiverType(), getMethodName, fieldType)
-			cl = cl.Append("        return %s.%s", g.getReceiverName(), fieldName)
-			cl = cl.Append("}")
-		} else if g.opts.getter {
+		if setter {
+			name := g.setterName(field)
+			setMethodName, err := g.resolveName(name)
+			if err != nil {
+				return nil, err
+			}
 			cl = cl.Append("")
-			cl = cl.Append("// %s already exists", getMethodName)
+			if setMethodName != "" {
+				var result string
+				if g.opts.chain {
+					result = "*" + g.getReceiverType()
+				}
+				decls, validationCodeLines, err := g.setterValidationCodeLines(field)
+				if err != nil {
+					return nil, err
+				}
+				hooks := g.hooks(field)
+				if len(validationCodeLines) > 0 || hooks["validate"].Err || hooks["beforeSet"].Err || hooks["afterSet"].Err {
+					if g.opts.chain {
+						return nil, fmt.Errorf("can't chain the setter of field %s of '%s', which returns errors", fieldName, g.Name)
+					}
+					result = "error"
+				}
+				if len(decls) > 0 {
+					cl = append(cl, decls...)
+					cl = cl.Append("")
+				}
+				cl = cl.Append("func (%s *%s) %s(%s %s) %s{", g.getReceiverName(), g.getReceiverType(), setMethodName, g.newValueName(), fieldType, result)
+				cl = append(cl, validationCodeLines...)
+				value := g.newValueName()
+				if g.cloned(field) {
+					var cloneCodeLines codeLines
+					cloneCodeLines, value = g.setterCloneCodeLines(field, fieldType)
+					cl = append(cl, cloneCodeLines...)
+					// the hooks get the clone to store, which is cloned once
+					_, validate := hooks["validate"]
+					_, beforeSet := hooks["beforeSet"]
+					if len(cloneCodeLines) == 0 && (validate || beforeSet) {
+						cl = cl.Append("        %s := %s", g.cloneName(), value)
+						value = g.cloneName()
+					}
+				}
+				if h, ok := hooks["validate"]; ok {
+					cl = append(cl, hookCallCodeLines(g.getReceiverName(), h, value)...)
+				}
+				cl = append(cl, l.codeLines(true)...)
+				current := g.getReceiverName() + "." + fieldName
+				if h, ok := hooks["beforeSet"]; ok {
+					cl = append(cl, hookCallCodeLines(g.getReceiverName(), h, current, value)...)
+				}
+				afterSet, ok := hooks["afterSet"]
+				if ok {
+					cl = cl.Append("        %s := %s", g.oldValueName(), current)
+				}
+				cl = cl.Append("        %s = %s", current, value)
+				if ok {
+					cl = append(cl, hookCallCodeLines(g.getReceiverName(), afterSet, g.oldValueName(), current)...)
+				}
+				if g.opts.chain {
+					cl = cl.Append("        return %s", g.getReceiverName())
+				} else if result == "error" {
+					cl = cl.Append("        return nil")
+				}
+				cl = cl.Append("}")
+				g.accessorMethods = append(g.accessorMethods, accessorMethod{name: setMethodName, typ: fieldType, result: result, setter: true})
+			} else {
+				cl = cl.Append("// %s already exists", name)
+			}
 		}
 
-		setMethodName := concat("set", g.opts.prefix, fieldName)
-		if g.opts.setter && !g.IsNameExist(setMethodName) {
-			cl = cl.Append("")
-			cl = cl.Append("func (%s *%s) %s(%s %s) {", g.getReceiverName(), g.getReceiverType(), setMethodName, g.newValueName(), fieldType)
-			cl = cl.Append("        %s.%s = %s", g.getReceiverName(), fieldName, g.newValueName())
-			cl = cl.Append("}")
-		} else if g.opts.setter {
-			cl = cl.Append("")
-			cl = cl.Append("// %s already exists", setMethodName)
+		if g.opts.wither && g.hasWither(field) {
+			witherCodeLines, err := g.getWitherCodeLines(field)
+			if err != nil {
+				return nil, err
+			}
+			cl = append(cl, witherCodeLines...)
+		}
+	}
+
+	if g.opts.validate {
+		validateCodeLines, err := g.getValidateCodeLines()
+		if err != nil {
+			return nil, err
+		}
+		cl = append(cl, validateCodeLines...)
+	}
+	if g.opts.copy {
+		cloneCodeLines, err := g.getCloneCodeLines()
+		if err != nil {
+			return nil, err
+		}
+		cl = append(cl, cloneCodeLines...)
+	}
+	if g.opts.builder {
+		builderCodeLines, err := g.getBuilderCodeLines()
+		if err != nil {
+			return nil, err
 		}
+		cl = append(cl, builderCodeLines...)
+	}
+	if g.opts.functionalOptions {
+		optionCodeLines, err := g.getFunctionalOptionCodeLines()
+		if err != nil {
+			return nil, err
+		}
+		cl = append(cl, optionCodeLines...)
+	}
+	if g.opts.constructor {
+		constructorCodeLines, err := g.getConstructorCodeLines()
+		if err != nil {
+			return nil, err
+		}
+		cl = append(cl, constructorCodeLines...)
 	}
 	return
 }
 
-func (g *Generator) getFieldCodeLines() (cl codeLines) {
+func (g *Generator) getFieldCodeLines() (cl codeLines, err error) {
+	cl, err = g.resolveLock()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, field := range g.Fields {
+		if !g.isFieldSelected(field) {
+			continue
+		}
 		fieldName, fieldType := field.Name, field.Type
-		if includes := g.opts.includes; len(includes) > 0 {
-			if _, ok := includes[fieldName]; !ok {
-				continue
+		l, err := g.fieldLock(field, g.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// check type arguments
+		fieldType, err = g.fillTypeArguments(fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("can't fill the type arguments of field '%s': %w", fieldName, err)
+		}
+
+		getter, setter := g.accessors(field)
+		if getter {
+			name := g.getterName(field)
+			getMethodName, err := g.resolveName(name)
+			if err != nil {
+				return nil, err
+			}
+			cl = cl.Append("")
+			if getMethodName != "" {
+				cl = cl.Append("func %s() %s {", getMethodName, fieldType)
+				var zero string
+				if g.opts.nilSafe {
+					zero, err = g.fillTypeArguments(field.Zero)
+					if err != nil {
+						return nil, fmt.Errorf("can't fill the type arguments of the zero value of field '%s': %w", fieldName, err)
+					}
+				}
+				if g.opts.nilSafe && g.Pointer {
+					cl = append(cl, nilCheckCodeLines(g.Name, zero)...)
+				}
+				cl = append(cl, l.codeLines(false)...)
+				if g.opts.nilSafe {
+					for _, pointer := range field.Pointers {
+						cl = append(cl, nilCheckCodeLines(g.Name+"."+pointer, zero)...)
+					}
+				}
+				if g.cloned(field) {
+					cl = append(cl, g.getterCloneCodeLines(field, fieldType, g.Name+"."+fieldName)...)
+				} else {
+					cl = cl.Append("        return %s.%s", g.Name, fieldName)
+				}
+				cl = cl.Append("}")
+			} else {
+				cl = cl.Append("// %s already exists", name)
 			}
 		}
 
-		if excludes := g.opts.excludes; len(excludes) > 0 {
-			if _, ok := excludes[fieldName]; ok {
-				continue
+		if setter {
+			name := g.setterName(field)
+			setMethodName, err := g.resolveName(name)
+			if err != nil {
+				return nil, err
+			}
+			cl = cl.Append("")
+			if setMethodName != "" {
+				decls, validationCodeLines, err := g.setterValidationCodeLines(field)
+				if err != nil {
+					return nil, err
+				}
+				var result string
+				if len(validationCodeLines) > 0 {
+					result = "error "
+				}
+				if len(decls) > 0 {
+					cl = append(cl, decls...)
+					cl = cl.Append("")
+				}
+				cl = cl.Append("func %s(%s %s) %s{", setMethodName, g.newValueName(), fieldType, result)
+				cl = append(cl, validationCodeLines...)
+				value := g.newValueName()
+				if g.cloned(field) {
+					var cloneCodeLines codeLines
+					cloneCodeLines, value = g.setterCloneCodeLines(field, fieldType)
+					cl = append(cl, cloneCodeLines...)
+				}
+				cl = append(cl, l.codeLines(true)...)
+				cl = cl.Append("        %s.%s = %s", g.Name, fieldName, value)
+				if result != "" {
+					cl = cl.Append("        return nil")
+				}
+				cl = cl.Append("}")
+			} else {
+				cl = cl.Append("// %s already exists", name)
 			}
 		}
+	}
+	return
+}
 
-		// check type arguments
-		fieldType = g.fillTypeArguments(fieldType)
+// nilCheckCodeLines returns the statements returning the zero value if the
+// pointer is nil, so the getters can be chained like the ones of protobuf.
+func nilCheckCodeLines(pointer, zero string) (cl codeLines) {
+	cl = cl.Append("        if %s == nil {", pointer)
+	cl = cl.Append("                return %s", zero)
+	cl = cl.Append("        }")
+	return
+}
 
-		getMethodName := concat(g.GetPrefix(), g.opts.prefix, fieldName)
-		if g.opts.getter && getMethodName != g.Name && getMethodName != g.Type {
-			cl = cl.Append("")
-			cl = cl.Append("func %s() %s {", getMethodName, fieldType)
-			cl = cl.Append("        return %s.%s", g.Name, fieldName)
-			cl = cl.Append("}")
-		} else if g.opts.getter {
-			cl = cl.Append("")
-			cl = cl.Append("// %s already exists", getMethodName)
+// resolveName checks the name to generate against the existing declarations,
+// and returns the name to use according to the conflict policy, or "" if the
+// name should be skipped.
+func (g *Generator) resolveName(name string) (string, error) {
+	if g.GeneratorType == GeneratorTypeStructure {
+		return g.resolve(name, "method")
+	}
+	return g.resolve(name, "function")
+}
+
+// resolveTypeName is resolveName for a generated type, which is declared in
+// the package scope even for a struct type.
+func (g *Generator) resolveTypeName(name string) (string, error) {
+	return g.resolve(name, "type")
+}
+
+func (g *Generator) resolve(name, kind string) (string, error) {
+	where, ok := g.conflict(name, kind)
+	if !ok {
+		g.declare(name, kind)
+		return name, nil
+	}
+
+	switch g.opts.conflict {
+	case ConflictFail:
+		return "", fmt.Errorf("%s of '%s' conflicts with the %s", name, g.Name, where)
+	case ConflictRename:
+		for i := 2; ; i++ {
+			alias := name + strconv.Itoa(i)
+			if _, ok := g.conflict(alias, kind); !ok {
+				debug.Printf("rename %s to %s, which conflicts with the %s", name, alias, where)
+				g.declare(alias, kind)
+				return alias, nil
+			}
 		}
+	default:
+		debug.Printf("skip %s, which conflicts with the %s", name, where)
+		return "", nil
+	}
+}
 
-		setMethodName := concat("set", g.opts.prefix, fieldName)
-		if g.opts.setter {
-			cl = cl.Append("")
-			cl = cl.Append("func %s(%s %s) {", setMethodName, g.newValueName(), fieldType)
-			cl = cl.Append("        %s.%s = %s", g.Name, fieldName, g.newValueName())
-			cl = cl.Append("}")
+// conflict reports whether the name of the kind conflicts with a declaration,
+// and where the declaration is. The methods of a struct conflict with its
+// methods and fields, and the others conflict with the package scope.
+func (g *Generator) conflict(name, kind string) (string, bool) {
+	if kind == "method" {
+		if where, ok := g.Methods[name]; ok {
+			return where, true
 		}
+		for _, field := range g.Fields {
+			// promoted fields are shadowed by the methods of the target
+			if field.Name == name && !field.Promoted {
+				return fmt.Sprintf("field %s of %s", name, g.Type), true
+			}
+		}
+		return "", false
+	}
+
+	if where, ok := g.Declared[name]; ok {
+		return where, true
+	}
+	if where, ok := g.Generated[name]; ok {
+		return where, true
 	}
+	return "", false
+}
+
+// declare records the generated name, so the following ones won't conflict
+// with it.
+func (g *Generator) declare(name, kind string) {
+	if kind == "method" {
+		if g.Methods == nil {
+			g.Methods = make(map[string]string)
+		}
+		g.Methods[name] = fmt.Sprintf("method generated for %s", g.Type)
+		return
+	}
+
+	if g.Generated == nil {
+		g.Generated = make(map[string]string)
+	}
+	g.Generated[name] = fmt.Sprintf("%s generated for '%s'", kind, g.Name)
+}
+
+// accessors returns whether the getter and setter of the field are generated,
+// the configuration of the field overrides the options.
+func (g *Generator) accessors(field Field) (getter, setter bool) {
+	if config := field.Config; config != nil && (config.Getter || config.Setter) {
+		return config.Getter, config.Setter
+	}
+	return g.opts.getter, g.opts.setter
+}
+
+func (g *Generator) getterName(field Field) string {
+	name, unexported := field.Name, false
+	if config := field.Config; config != nil {
+		if config.GetterName != "" {
+			name = config.GetterName
+		}
+		unexported = config.UnexportedGetter
+	}
+	return methodName(concat(g.GetPrefix(), g.opts.prefix, name), unexported)
+}
+
+func (g *Generator) setterName(field Field) string {
+	return g.modifierName("set", field)
+}
+
+func (g *Generator) witherName(field Field) string {
+	return g.modifierName("with", field)
+}
+
+// modifierName returns the name of a setter or a wither, which is configured
+// by the setter configuration of the field.
+func (g *Generator) modifierName(verb string, field Field) string {
+	name, unexported := field.Name, false
+	if config := field.Config; config != nil {
+		if config.SetterName != "" {
+			name = config.SetterName
+		}
+		unexported = config.UnexportedSetter
+	}
+	return methodName(concat(verb, g.opts.prefix, name), unexported)
+}
+
+// hasWither reports whether the field has a wither in wither mode, which is
+// generated unless the field is configured without a setter. The fields
+// promoted through embedded pointers have no withers, since setting them on
+// the copy would modify the original as well.
+func (g *Generator) hasWither(field Field) bool {
+	if field.Indirect {
+		return false
+	}
+	if config := field.Config; config != nil && (config.Getter || config.Setter) {
+		return config.Setter
+	}
+	return true
+}
+
+// getWitherCodeLines returns the wither of the field, whose value receiver is
+// a copy of the struct, so the modified copy is returned and the original is
+// left untouched.
+func (g *Generator) getWitherCodeLines(field Field) (cl codeLines, err error) {
+	for _, f := range g.Fields {
+		if f.LockKind != "" && !strings.HasPrefix(f.Type, "*") {
+			return nil, fmt.Errorf("can't generate withers for '%s', whose lock field %s can't be copied", g.Name, f.Name)
+		}
+	}
+
+	name := g.witherName(field)
+	witherName, err := g.resolveName(name)
+	if err != nil {
+		return nil, err
+	}
+	cl = cl.Append("")
+	if witherName == "" {
+		return cl.Append("// %s already exists", name), nil
+	}
+	cl = cl.Append("func (%s %s) %s(%s %s) %s {", g.getReceiverName(), g.getReceiverType(), witherName, g.newValueName(), field.Type, g.getReceiverType())
+	value := g.newValueName()
+	if g.cloned(field) {
+		var cloneCodeLines codeLines
+		cloneCodeLines, value = g.setterCloneCodeLines(field, field.Type)
+		cl = append(cl, cloneCodeLines...)
+	}
+	cl = cl.Append("        %s.%s = %s", g.getReceiverName(), field.Name, value)
+	cl = cl.Append("        return %s", g.getReceiverName())
+	cl = cl.Append("}")
 	return
 }
 
+// methodName returns the name of an accessor, which is unexported if the field
+// configures so.
+func methodName(name string, unexported bool) string {
+	if unexported {
+		return lower(name)
+	}
+	return name
+}
+
+func (g *Generator) isFieldSelected(field Field) bool {
+	if field.Config != nil && field.Config.Skip {
+		return false
+	}
+	// the locks are not accessed but guard the accessors in sync mode
+	if field.LockKind != "" && g.opts.sync {
+		return false
+	}
+	if field.Embedded && !g.opts.embed {
+		return false
+	}
+	if field.Promoted && !g.opts.promote {
+		return false
+	}
+
+	if includes := g.opts.includes; len(includes) > 0 {
+		if _, ok := includes[field.Name]; !ok {
+			return false
+		}
+	}
+
+	if excludes := g.opts.excludes; len(excludes) > 0 {
+		if _, ok := excludes[field.Name]; ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (g *Generator) getReceiverName() string {
 	if g.ReceiverName != "" {
 		return g.ReceiverName
 	}
-	g.ReceiverName = strings.ToLower(g.Type[:1])
+	g.ReceiverName = initial(g.Type)
 	return g.ReceiverName
 }
 
@@ -369,20 +1186,43 @@ func (g *Generator) newValueName() string {
 }
 
 func (g *Generator) getReceiverType() string {
+	return g.instantiate(g.Name)
+}
+
+// instantiate returns the generic type or function declared for the target
+// instantiated with the type parameters of the target.
+func (g *Generator) instantiate(name string) string {
 	if len(g.TypeParams) == 0 {
-		return g.Name
+		return name
 	}
-	return g.Name + "[" + strings.Join(g.TypeParams, ", ") + "]"
+	return name + "[" + strings.Join(g.TypeParams, ", ") + "]"
 }
 
-func (g *Generator) fillTypeArguments(t string) string {
+// getTypeParamList returns the type parameter list declaring the type
+// parameters of the target, or "" if the target isn't generic.
+func (g *Generator) getTypeParamList() string {
+	if len(g.TypeParams) == 0 {
+		return ""
+	}
+	decls := make([]string, len(g.TypeParams))
 	for i, param := range g.TypeParams {
-		t = fillTypeArguments(t, param, g.TypeArguments[i])
+		decls[i] = param + " " + g.TypeConstraints[i]
 	}
-	return t
+	return "[" + strings.Join(decls, ", ") + "]"
 }
 
-func (g *Generator) writeFile(cl codeLines) error {
+func (g *Generator) fillTypeArguments(t string) (string, error) {
+	if len(g.TypeParams) == 0 {
+		return t, nil
+	}
+	args := make(map[string]string, len(g.TypeParams))
+	for i, param := range g.TypeParams {
+		args[param] = g.TypeArguments[i]
+	}
+	return fillTypeArguments(t, args)
+}
+
+func (g *Generator) writeFile(path string, imports []Import, cl codeLines) error {
 	var sb strings.Builder
 	for _, line := range cl {
 		_, err := fmt.Fprintf(&sb, line.format+"\n", line.a...)
@@ -391,14 +1231,14 @@ func (g *Generator) writeFile(cl codeLines) error {
 		}
 	}
 
-	formatted, err := format.Source([]byte(sb.String()))
+	formatted, err := removeUnusedImports(sb.String(), imports)
 	if err != nil {
-		return fmt.Errorf("format.Source: %w", err)
+		return fmt.Errorf("removeUnusedImports: %w", err)
 	}
 
-	f, err := os.Create(g.FilePath())
+	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("os.Create(%s): %w", g.FilePath(), err)
+		return fmt.Errorf("os.Create(%s): %w", path, err)
 	}
 	defer f.Close()
 
@@ -408,7 +1248,34 @@ func (g *Generator) writeFile(cl codeLines) error {
 	return nil
 }
 
+// removeUnusedImports removes the imports which are not referred by the
+// generated code, since imports are recorded for every type of the target,
+// and formats the code.
+func removeUnusedImports(src string, imports []Import) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	for _, ipt := range imports {
+		if !astutil.UsesImport(file, ipt.Path) {
+			astutil.DeleteNamedImport(fset, file, ipt.specName(), ipt.Path)
+		}
+	}
+	ast.SortImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("format.Node: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 func (g *Generator) FilePath() string {
+	if g.Test {
+		return filepath.Join(g.Dir, strings.TrimSuffix(g.FileName, "_test")+strings.ToLower(g.Name)+"_goaccessor_test.go")
+	}
 	return filepath.Join(g.Dir, g.FileName+strings.ToLower(g.Name)+"_goaccessor.go")
 }
 
@@ -419,18 +1286,6 @@ func (g *Generator) GetPrefix() string {
 	return "get"
 }
 
-func (g *Generator) IsNameExist(name string) bool {
-	if _, ok := g.Methods[name]; ok {
-		return true
-	}
-	for _, field := range g.Fields {
-		if field.Name == name {
-			return true
-		}
-	}
-	return false
-}
-
 // helper functions
 
 func concat(strs ...string) (s string) {
@@ -444,35 +1299,57 @@ func concat(strs ...string) (s string) {
 }
 
 func upper(str string) string {
-	return strings.ToUpper(str[:1]) + str[1:]
+	r, size := utf8.DecodeRuneInString(str)
+	return string(unicode.ToUpper(r)) + str[size:]
 }
 
-func fillTypeArguments(t, param, arg string) string {
-	re := regexp.MustCompile(`(^|[^_0-9\p{L}])` + regexp.QuoteMeta(param) + `($|[^_0-9\p{L}])`)
-	return re.ReplaceAllStringFunc(t, func(s string) string {
-		if strings.HasPrefix(s, param) {
-			return arg + s[len(param):]
-		} else if strings.HasSuffix(s, param) {
-			return s[:len(s)-len(param)] + arg
-		} else {
-			return strings.ReplaceAll(s, param, arg)
-		}
-	})
+func lower(str string) string {
+	r, size := utf8.DecodeRuneInString(str)
+	return string(unicode.ToLower(r)) + str[size:]
 }
 
-func getPackageNameFromType(typeName string) string {
-	if !strings.Contains(typeName, ".") {
-		return ""
-	}
+// initial returns the first letter of str in lower case.
+func initial(str string) string {
+	r, _ := utf8.DecodeRuneInString(str)
+	return string(unicode.ToLower(r))
+}
 
-	// hacky way to handle anonymous types and generic types
-	if strings.Contains(typeName, "{") || strings.Contains(typeName, "[") {
-		return ""
+// fillTypeArguments replaces the type parameters in the type expression t with
+// the type arguments in args. All parameters are replaced at once, so an
+// argument is never substituted again; package qualifiers, field names and
+// parameter names are left untouched.
+func fillTypeArguments(t string, args map[string]string) (string, error) {
+	expr, err := parser.ParseExpr(t)
+	if err != nil {
+		return "", fmt.Errorf("parser.ParseExpr(%s): %w", t, err)
 	}
 
-	pkgName := strings.Split(typeName, ".")[0]
+	argExprs := make(map[string]ast.Expr, len(args))
+	for param, arg := range args {
+		argExpr, err := parser.ParseExpr(arg)
+		if err != nil {
+			return "", fmt.Errorf("parser.ParseExpr(%s): %w", arg, err)
+		}
+		argExprs[param] = argExpr
+	}
 
-	// handle pointer type
-	pkgName = strings.TrimLeft(pkgName, "*")
-	return pkgName
+	expr = astutil.Apply(expr, func(c *astutil.Cursor) bool {
+		switch c.Parent().(type) {
+		case *ast.SelectorExpr:
+			// the package qualifier and the selected name
+			return false
+		case *ast.Field:
+			if c.Name() == "Names" {
+				return false
+			}
+		}
+		if ident, ok := c.Node().(*ast.Ident); ok {
+			if argExpr, ok := argExprs[ident.Name]; ok {
+				c.Replace(argExpr)
+				return false
+			}
+		}
+		return true
+	}, nil).(ast.Expr)
+	return types.ExprString(expr), nil
 }
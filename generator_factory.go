@@ -1,107 +1,221 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
 	"go/parser"
-	"go/printer"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
 )
 
 type generatorFactory struct {
-	dir        string
-	pkg        string
+	dir string
+	// pkgs are the package in dir and its external test package, the former
+	// includes the test files of the package.
+	pkgs       []*packages.Package
 	generators map[string]*Generator
+	// targetPkgs records the package where each target is declared.
+	targetPkgs map[string]*packages.Package
 
-	curFileName     string
-	curFset         *token.FileSet
-	curImports      []string
-	curNamedImports map[string]string
-	lastType        string
-}
+	// fieldNodes indexes the struct fields of all loaded packages, see
+	// fieldNode.
+	fieldNodes map[token.Pos]*ast.Field
 
-func NewGenerators(targets []string, dir string, field bool) ([]*Generator, error) {
-	factory := &generatorFactory{dir: dir}
+	// bodyInfos caches the type information of the function bodies of the
+	// packages, see variableUses.
+	bodyInfos map[*packages.Package]*types.Info
+
+	pkg               *packages.Package
+	curFileName       string
+	curFileConstraint constraint.Expr
+}
 
-	if err := factory.walkDir(factory.inspectPkg); err != nil {
-		return nil, fmt.Errorf("factory.walkDir: %w", err)
+// NewGenerators loads the package in dir and creates the generators of its
+// targets.
+func NewGenerators(targets []string, dir string, field bool, build buildConfig) ([]*Generator, error) {
+	loaded, err := loadPackages(dir, []string{"."}, build)
+	if err != nil {
+		return nil, fmt.Errorf("loadPackages: %w", err)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Abs: %w", err)
 	}
+	pkgs, ok := loaded[absDir]
+	if !ok {
+		return nil, fmt.Errorf("no package in %s", dir)
+	}
+	return newGenerators(targets, dir, field, pkgs)
+}
+
+// newGenerators creates the generators of the targets declared in pkgs, the
+// packages loaded from dir.
+func newGenerators(targets []string, dir string, field bool, pkgs []*packages.Package) ([]*Generator, error) {
+	factory := &generatorFactory{dir: dir, pkgs: pkgs}
 
 	if err := factory.initGenerators(targets); err != nil {
 		return nil, fmt.Errorf("factory.initGenerators: %w", err)
 	}
 
-	if err := factory.walkDir(factory.inspectDeclaration); err != nil {
-		return nil, fmt.Errorf("factory.walkDir: %w", err)
+	if err := factory.walkFiles(factory.inspectDeclaration); err != nil {
+		return nil, fmt.Errorf("factory.walkFiles: %w", err)
 	}
 
-	if field {
-		variables, err := factory.replaceVariableGenerators()
-		if err != nil {
-			return nil, fmt.Errorf("factory.replaceVariableGenerators: %w", err)
-		}
-
-		if err := factory.walkDir(factory.inspectDeclaration); err != nil {
-			return nil, fmt.Errorf("factory.walkDir: %w", err)
-		}
+	if err := factory.inspectDeclared(); err != nil {
+		return nil, fmt.Errorf("factory.inspectDeclared: %w", err)
+	}
 
-		if err := factory.insertVariablesGenerators(variables); err != nil {
-			return nil, fmt.Errorf("insertVariablesGenerators: %w", err)
+	if field {
+		if err := factory.replaceFieldGenerators(); err != nil {
+			return nil, fmt.Errorf("factory.replaceFieldGenerators: %w", err)
 		}
 	}
 
-	var result []*Generator
+	// the generators are sorted, so the names conflicting with each other are
+	// resolved in the same way every time
+	result := make([]*Generator, 0, len(factory.generators))
 	for _, generator := range factory.generators {
 		result = append(result, generator)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
 	return result, nil
 }
 
-func (f *generatorFactory) walkDir(fn func(file *ast.File) error) error {
-	if f.dir == "" {
-		return fmt.Errorf("no dir specified")
+// loadPackages loads the packages matching the patterns in dir with full type
+// information, and groups them by their directories. The packages are loaded
+// with their tests, see selectTestVariants.
+func loadPackages(dir string, patterns []string, build buildConfig) (map[string][]*packages.Package, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no dir specified")
 	}
-	return filepath.Walk(f.dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() && path != f.dir {
-			return filepath.SkipDir
+
+	cfg := &packages.Config{
+		Mode:      packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:       dir,
+		Env:       os.Environ(),
+		Tests:     true,
+		ParseFile: parseFile,
+	}
+	if build.goos != "" {
+		cfg.Env = append(cfg.Env, "GOOS="+build.goos)
+	}
+	if build.goarch != "" {
+		cfg.Env = append(cfg.Env, "GOARCH="+build.goarch)
+	}
+	if len(build.tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(build.tags, ",")}
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("packages.Load %s %s: %w", dir, patterns, err)
+	}
+
+	loaded := make(map[string][]*packages.Package)
+	for _, pkg := range selectTestVariants(pkgs) {
+		for _, err := range pkg.Errors {
+			// The declarations of generated files are dropped, so the rest of the
+			// package may fail to type check, which is fine for our purpose.
+			if err.Kind == packages.TypeError {
+				debug.Printf("ignore type error: %s\n", err)
+				continue
+			}
+			return nil, fmt.Errorf("load package %s: %w", pkg.ID, err)
 		}
-		if filepath.Ext(info.Name()) != ".go" {
-			return nil
+		if pkg.Types == nil || pkg.TypesInfo == nil || len(pkg.GoFiles) == 0 {
+			return nil, fmt.Errorf("no type information for package %s", pkg.ID)
 		}
-		if strings.HasSuffix(info.Name(), "_goaccessor.go") {
-			return nil
+		pkgDir := filepath.Dir(pkg.GoFiles[0])
+		loaded[pkgDir] = append(loaded[pkgDir], pkg)
+	}
+	return loaded, nil
+}
+
+// selectTestVariants returns the packages to inspect among the ones loaded
+// with tests: the test variant of a package replaces the package since it
+// includes the test files as well, and the test executables are dropped.
+func selectTestVariants(pkgs []*packages.Package) []*packages.Package {
+	// the IDs of test variants look like "p [p.test]"
+	variants := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if strings.Contains(pkg.ID, " [") {
+			variants[pkg.PkgPath] = true
 		}
-		f.curFileName = strings.TrimSuffix(info.Name(), ".go")
-		f.curFset = token.NewFileSet()
-		file, err := parser.ParseFile(f.curFset, path, nil, parser.ParseComments)
-		if err != nil {
-			return fmt.Errorf("parser.ParseFile %s: %w", path, err)
+	}
+
+	var result []*packages.Package
+	for _, pkg := range pkgs {
+		switch {
+		case strings.HasSuffix(pkg.ID, ".test"):
+			debug.Printf("skip test executable %s\n", pkg.ID)
+		case !strings.Contains(pkg.ID, " [") && variants[pkg.PkgPath]:
+			debug.Printf("skip package %s in favor of its test variant\n", pkg.ID)
+		default:
+			result = append(result, pkg)
 		}
-		debug.Printf("begin to parse file %s\n", path)
-		return fn(file)
+	}
+	// the external test package goes last
+	sort.SliceStable(result, func(i, j int) bool {
+		return !strings.HasSuffix(result[i].Name, "_test") && strings.HasSuffix(result[j].Name, "_test")
 	})
+	return result
 }
 
-func (f *generatorFactory) inspectPkg(file *ast.File) error {
-	switch pkg := file.Name.Name; {
-	case f.pkg == "":
+func (f *generatorFactory) walkFiles(fn func(file *ast.File) error) error {
+	for _, pkg := range f.pkgs {
 		f.pkg = pkg
-	case f.pkg != pkg:
-		return fmt.Errorf("package name mismatch: %s!= %s", f.pkg, pkg)
+		for _, file := range pkg.Syntax {
+			path := pkg.Fset.Position(file.Package).Filename
+			if isGeneratedFile(path) {
+				continue
+			}
+			expr, err := fileConstraint(file, path)
+			if err != nil {
+				return fmt.Errorf("fileConstraint %s: %w", path, err)
+			}
+			f.curFileName = strings.TrimSuffix(filepath.Base(path), ".go")
+			f.curFileConstraint = expr
+			debug.Printf("begin to inspect file %s\n", path)
+			if err := fn(file); err != nil {
+				return fmt.Errorf("inspect file %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// locate records the current file and package as the place where the target
+// of g is declared.
+func (f *generatorFactory) locate(g *Generator) error {
+	if pkg, ok := f.targetPkgs[g.Name]; ok && pkg != f.pkg {
+		return fmt.Errorf("'%s' is declared in both package %s and %s", g.Name, pkg.Name, f.pkg.Name)
+	}
+	f.targetPkgs[g.Name] = f.pkg
+
+	g.Pkg = f.pkg.Name
+	g.PkgPath = f.pkg.PkgPath
+	g.FileName = f.curFileName
+	g.Test = strings.HasSuffix(g.FileName, "_test")
+	if f.curFileConstraint != nil {
+		g.BuildConstraint = f.curFileConstraint.String()
 	}
 	return nil
 }
 
 func (f *generatorFactory) initGenerators(targets []string) error {
-	if len(targets) == 0 || f.dir == "" || f.pkg == "" {
-		return fmt.Errorf("these fields must be non-empty, targets %s, f.dir %s, f.pkg %s", targets, f.dir, f.pkg)
+	if len(targets) == 0 || f.dir == "" || len(f.pkgs) == 0 {
+		return fmt.Errorf("these fields must be non-empty, targets %s, f.dir %s, f.pkgs %v", targets, f.dir, f.pkgs)
 	}
 
 	generators := make(map[string]*Generator, len(targets))
@@ -109,165 +223,73 @@ func (f *generatorFactory) initGenerators(targets []string) error {
 		generators[target] = &Generator{
 			Name:    target,
 			Dir:     f.dir,
-			Pkg:     f.pkg,
+			Pkg:     f.pkgs[0].Name,
 			Fields:  make([]Field, 0),
-			Methods: make(map[string]struct{}),
+			Methods: make(map[string]string),
 		}
 	}
 
 	f.generators = generators
+	f.targetPkgs = make(map[string]*packages.Package, len(targets))
 	return nil
 }
 
-func (f *generatorFactory) replaceVariableGenerators() (variables map[string]*Generator, err error) {
-	variables = f.generators
-	types := make(map[string]*Generator, len(variables))
-	for _, v := range variables {
-		if v.GeneratorType != GeneratorTypeVariable {
-			return nil, fmt.Errorf("unexpected variable generator type %s", v.GeneratorType)
-		}
-
-		referType := v.Type
-		// simple solution for type parameter
-		if idx := strings.Index(referType, "["); idx != -1 {
-			referType = referType[:idx]
-		}
-		// simple solution for pointer type
-		referType = strings.TrimPrefix(referType, "*")
-		if _, ok := types[referType]; ok {
-			continue
-		}
-
-		types[referType] = &Generator{
-			Name:    referType,
-			Dir:     f.dir,
-			Pkg:     f.pkg,
-			Fields:  make([]Field, 0),
-			Methods: make(map[string]struct{}),
-		}
-		debug.Printf("Replace variable %+v with %+v\n", v, types[referType])
-	}
-	f.generators = types
-	return
-}
-
-func (f *generatorFactory) insertVariablesGenerators(variables map[string]*Generator) error {
-	newVariables := make(map[string]*Generator, len(variables))
-	for k, v := range variables {
+// replaceFieldGenerators turns the variable generators into field generators,
+// whose fields come from the struct type of the variables.
+func (f *generatorFactory) replaceFieldGenerators() error {
+	for name, v := range f.generators {
 		if v.GeneratorType != GeneratorTypeVariable {
 			return fmt.Errorf("unexpected variable generator type %s", v.GeneratorType)
 		}
 
-		// skip anonymous types
+		// anonymous structs have been handled in inspectValueSpec
 		if len(v.Fields) > 0 {
 			v.GeneratorType = GeneratorTypeField
-			newVariables[k] = v
 			continue
 		}
 
-		referType := v.Type
-		// simple solution for type parameter
-		if idx := strings.Index(referType, "["); idx != -1 {
-			referType = referType[:idx]
-		}
-		// simple solution for pointer type
-		referType = strings.TrimPrefix(referType, "*")
-		t, ok := f.generators[referType]
+		f.pkg = f.targetPkgs[name]
+		t := deref(f.pkg.Types.Scope().Lookup(name).Type())
+		named, ok := t.(*types.Named)
 		if !ok {
-			continue
+			return fmt.Errorf("field accessors are only supported for struct types, '%s' is %s", name, t)
 		}
-
-		if t.GeneratorType != GeneratorTypeStructure {
-			return fmt.Errorf("unexpected structure generator type %s", t.GeneratorType)
+		// The struct type may come from other packages, whose unexported
+		// fields are skipped by parseFields.
+		origin := named.Origin()
+		if err := f.checkResolved(origin.Obj().Name(), origin.Underlying(), origin.Obj().Pos()); err != nil {
+			return err
+		}
+		if _, ok := origin.Underlying().(*types.Struct); !ok {
+			return fmt.Errorf("field accessors are only supported for struct types, '%s' is %s", name, t)
 		}
 
-		newVariables[k] = &Generator{
-			Name:          k,
-			Dir:           v.Dir,
-			Pkg:           v.Pkg,
-			Type:          referType,
-			TypeParams:    t.TypeParams,
-			TypeArguments: v.TypeArguments,
-			ReceiverName:  t.ReceiverName,
-			Fields:        t.Fields,
-			Methods:       t.Methods,
-			GeneratorType: GeneratorTypeField,
-			FileName:      v.FileName,
-			Imports:       v.Imports,
+		v.Type = origin.Obj().Name()
+		v.TypeParams = typeParams(origin)
+		fields, err := f.parseFields(v, origin)
+		if err != nil {
+			return fmt.Errorf("can't parse the fields of '%s': %w", name, err)
 		}
-		debug.Printf("Insert new variable %+v\n", newVariables[k])
+		v.Fields = fields
+		v.GeneratorType = GeneratorTypeField
+		debug.Printf("Replace variable %s with %+v\n", name, v)
 	}
-	f.generators = newVariables
 	return nil
 }
 
 func (f *generatorFactory) inspectDeclaration(file *ast.File) error {
-	f.curImports = make([]string, 0)
-	f.curNamedImports = make(map[string]string, 0)
-	undeclaredGenerators := make([]*Generator, 0, len(f.generators))
-	for _, g := range f.generators {
-		if g.Type == "" {
-			undeclaredGenerators = append(undeclaredGenerators, g)
-		}
-	}
-
-	var err error
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch decl := n.(type) {
-		case *ast.ImportSpec:
-			err = f.inspectImportSpecification(decl)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
 		case *ast.GenDecl:
-			err = f.inspectGenericDeclaration(decl)
-			if err != nil {
-				err = fmt.Errorf("f.inspectGenericDeclaration: %w", err)
-				return false
+			if err := f.inspectGenericDeclaration(decl); err != nil {
+				return fmt.Errorf("f.inspectGenericDeclaration: %w", err)
 			}
 		case *ast.FuncDecl:
-			err = f.inspectFunctionDeclaration(decl)
-			if err != nil {
-				err = fmt.Errorf("f.inspectFunctionDeclaration: %w", err)
-				return false
+			if err := f.inspectFunctionDeclaration(decl); err != nil {
+				return fmt.Errorf("f.inspectFunctionDeclaration: %w", err)
 			}
 		}
-		return true
-	})
-	if err != nil {
-		return err
 	}
-
-	// The new declared generators should inspect imports for its types.
-	for _, g := range undeclaredGenerators {
-		if g.Type == "" {
-			continue
-		}
-		if err := g.InspectImports(f.curImports, f.curNamedImports); err != nil {
-			return fmt.Errorf("g.InspectImports %v %v: %w", f.curImports, f.curNamedImports, err)
-		}
-	}
-	return nil
-}
-
-func (f *generatorFactory) inspectImportSpecification(decl *ast.ImportSpec) error {
-	path, err := parseNode(f.curFset, decl.Path)
-	if err != nil {
-		return fmt.Errorf("parseNode: %w", err)
-	}
-
-	name := ""
-	if decl.Name != nil {
-		name = decl.Name.String()
-	}
-	if name == "_" || name == "." {
-		// FIXME: explicit cases should be considered, but we ignore them for simplicity.
-		return nil
-	}
-
-	if name != "" {
-		f.curNamedImports[name] = fmt.Sprintf("%s %s", name, path)
-		return nil
-	}
-
-	f.curImports = append(f.curImports, path)
 	return nil
 }
 
@@ -287,216 +309,491 @@ func (f *generatorFactory) inspectGenericDeclaration(decl *ast.GenDecl) error {
 	return nil
 }
 
-// TODO refactor this
 func (f *generatorFactory) inspectValueSpec(spec *ast.ValueSpec) error {
-	var specType string
-	var typeArguments []string
-	var fields []Field
-	if t := spec.Type; t != nil {
-		s, err := parseNode(f.curFset, t)
-		if err != nil {
-			return fmt.Errorf("parseNode: %w", err)
+	for _, name := range spec.Names {
+		generator, ok := f.generators[name.Name]
+		if !ok {
+			continue
 		}
-		specType = s
 
-		if expr, ok := t.(*ast.StarExpr); ok {
-			t = expr.X
+		obj := f.pkg.TypesInfo.Defs[name]
+		if obj == nil {
+			return fmt.Errorf("can't find the object of '%s'", name.Name)
 		}
-		// handler type arguments
-		typeArguments, err = parseTypeArguments(f.curFset, t)
-		if err != nil {
-			return fmt.Errorf("parseTypeArguments: %w", err)
+		t := obj.Type()
+		if err := f.checkResolved(name.Name, t, name.Pos()); err != nil {
+			return err
 		}
-
-		// handler anonymous structs
-		if structType, ok := t.(*ast.StructType); ok {
-			fields, err = f.parseFields(structType.Fields.List)
-			if err != nil {
-				return fmt.Errorf("f.parseFields: %w", err)
+		if c, ok := obj.(*types.Const); ok {
+			// untyped constants are accessed through their default types
+			t = types.Default(t)
+			if err := f.checkConstant(c, t); err != nil {
+				return fmt.Errorf("f.checkConstant: %w", err)
 			}
 		}
-	}
 
-	lit, _ := parseNode(f.curFset, spec)
-	debug.Printf("Inspecting %s", lit)
-
-	for i, name := range spec.Names {
-		debug.Printf("Inspecting %dth name %s ......\n", i, name.Name)
-		generator, ok := f.generators[name.Name]
-		if !ok {
-			continue
+		if err := f.locate(generator); err != nil {
+			return err
 		}
-
-		generator.FileName = f.curFileName
 		generator.GeneratorType = GeneratorTypeVariable
-		generator.Type = specType
-		if generator.Type == "" {
-			if i >= len(spec.Values) {
-				// for const declaration list, the expression may be omitted,
-				// which leads to a shorter Values slice.
-				generator.Type = f.lastType
-				debug.Printf("Assign lastType %s to %s", f.lastType, name.Name)
-				continue
+		generator.Type = f.typeString(generator, t)
+		_, generator.Pointer = types.Unalias(t).(*types.Pointer)
+		debug.Printf("Type of '%s' is %s\n", name.Name, generator.Type)
+		if v, ok := obj.(*types.Var); ok {
+			generator.AtomicType, generator.AtomicValueType = f.atomicType(generator, t)
+			generator.Comparable = types.Comparable(t)
+			uses, err := f.variableUses(v)
+			if err != nil {
+				return fmt.Errorf("f.variableUses: %w", err)
 			}
-			debug.Printf("spec.Values[%d] is %T\n", i, spec.Values[i])
-			kind := ""
-			switch v := spec.Values[i].(type) {
-			case *ast.BasicLit:
-				kind = v.Kind.String()
-			case *ast.UnaryExpr:
-				s, err := f.parseUnaryExpression(v)
-				if err != nil {
-					return fmt.Errorf("f.parseUnaryExpression: %w", err)
-				}
-				kind = s
-
-				if lit, ok := v.X.(*ast.CompositeLit); ok {
-					// handler type arguments
-					debug.Printf("type of lit.Type is %T", lit.Type)
-					generator.TypeArguments, err = parseTypeArguments(f.curFset, lit.Type)
-					if err != nil {
-						return fmt.Errorf("parseTypeArguments: %w", err)
-					}
-					// handler anonymous struct fields
-					if structType, ok := lit.Type.(*ast.StructType); ok {
-						fields, err := f.parseFields(structType.Fields.List)
-						if err != nil {
-							return fmt.Errorf("f.parseFields: %w", err)
-						}
-						generator.Fields = fields
-					}
-				}
-			case *ast.Ident: // case for iota
-				if v.Name == "iota" {
-					kind = "INT"
-				}
-			case *ast.CompositeLit:
-				s, err := parseNode(f.curFset, v.Type)
-				if err != nil {
-					return fmt.Errorf("parseNode: %w", err)
-				}
-				kind = s
+			generator.Uses = uses
+		}
 
-				// handler type arguments
-				generator.TypeArguments, err = parseTypeArguments(f.curFset, v.Type)
-				if err != nil {
-					return fmt.Errorf("parseTypeArguments: %w", err)
-				}
-				// handler anonymous struct fields
-				if structType, ok := v.Type.(*ast.StructType); ok {
-					fields, err := f.parseFields(structType.Fields.List)
-					if err != nil {
-						return fmt.Errorf("f.parseFields: %w", err)
-					}
-					generator.Fields = fields
-				}
+		switch t := deref(t).(type) {
+		case *types.Named:
+			// handle type arguments
+			typeArgs := t.TypeArgs()
+			for i := 0; i < typeArgs.Len(); i++ {
+				generator.TypeArguments = append(generator.TypeArguments, f.typeString(generator, typeArgs.At(i)))
 			}
-
-			switch kind {
-			case "INT":
-				generator.Type = "int"
-			case "FLOAT":
-				generator.Type = "float64"
-			case "IMAG":
-				generator.Type = "complex128"
-			case "CHAR":
-				generator.Type = "rune"
-			case "STRING":
-				generator.Type = "string"
-			default:
-				generator.Type = kind
+		case *types.Struct:
+			// handle anonymous struct fields
+			fields, err := f.parseFields(generator, t)
+			if err != nil {
+				return fmt.Errorf("can't parse the fields of '%s': %w", name.Name, err)
 			}
-		}
-		generator.Type = strings.TrimSpace(generator.Type)
-		debug.Printf("generator.Type = %s\n", generator.Type)
-		if generator.Type == "" {
-			return fmt.Errorf("can't infer type for '%s'", name.Name)
-		}
-		debug.Printf("Type of '%s' is %s\n", name.Name, generator.Type)
-		f.lastType = generator.Type
-
-		if len(typeArguments) > 0 && len(generator.TypeArguments) == 0 {
-			generator.TypeArguments = typeArguments
-		}
-		if len(fields) > 0 && len(generator.Fields) == 0 {
 			generator.Fields = fields
 		}
 	}
 	return nil
 }
 
-func (f *generatorFactory) parseUnaryExpression(expr *ast.UnaryExpr) (string, error) {
-	var kind string
-	switch v := expr.X.(type) {
-	case *ast.BasicLit:
-		kind = v.Kind.String()
-	case *ast.CompositeLit:
-		s, err := parseNode(f.curFset, v.Type)
-		if err != nil {
-			return "", fmt.Errorf("parseNode: %w", err)
-		}
-		kind = s
-	default:
-		return "", fmt.Errorf("unsupported expression type: %T", v)
-	}
-	if kind == "" {
-		return "", nil
+// checkConstant reports an error if the value of the untyped constant c can't
+// be represented by its default type t, e.g. const Huge = 1 << 100.
+func (f *generatorFactory) checkConstant(c *types.Const, t types.Type) error {
+	if basic, ok := c.Type().(*types.Basic); !ok || basic.Info()&types.IsUntyped == 0 {
+		return nil
 	}
-	if expr.Op == token.AND {
-		kind = "*" + kind
+
+	expr := fmt.Sprintf("%s(%s)", types.TypeString(t, nil), c.Name())
+	if _, err := types.Eval(f.pkg.Fset, f.pkg.Types, token.NoPos, expr); err != nil {
+		return fmt.Errorf("constant %s can't be returned as its default type %s: %w", c.Name(), t, err)
 	}
-	return kind, nil
+	return nil
 }
 
+// inspectTypeSpec inspects struct types, as well as the aliases and defined
+// types whose underlying types are structs.
 func (f *generatorFactory) inspectTypeSpec(spec *ast.TypeSpec) error {
 	generator, ok := f.generators[spec.Name.Name]
 	if !ok {
 		return nil
 	}
 
-	structType, ok := spec.Type.(*ast.StructType)
+	obj, ok := f.pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
 	if !ok {
+		return fmt.Errorf("can't find the type name of '%s'", spec.Name.Name)
+	}
+	if err := f.checkResolved(spec.Name.Name, obj.Type().Underlying(), spec.Name.Pos()); err != nil {
+		return err
+	}
+	if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		debug.Printf("Skip type %s of underlying type %s\n", spec.Name.Name, obj.Type().Underlying())
 		return nil
 	}
 
-	generator.Type = spec.Name.Name
-	if spec.TypeParams != nil {
-		for _, typeParam := range spec.TypeParams.List {
-			for _, name := range typeParam.Names {
-				generator.TypeParams = append(generator.TypeParams, name.Name)
-			}
+	if obj.IsAlias() {
+		// The methods of an alias are declared on the aliased type, which
+		// must be a type defined in the current package.
+		named, ok := types.Unalias(obj.Type()).(*types.Named)
+		if !ok || named.Obj().Pkg() != f.pkg.Types || named.TypeArgs().Len() > 0 {
+			return fmt.Errorf("can't define methods on '%s', which is an alias of the non-local or instantiated type %s", spec.Name.Name, obj.Type())
+		}
+		for i := 0; i < named.NumMethods(); i++ {
+			method := named.Method(i)
+			generator.Methods[method.Name()] = fmt.Sprintf("method declared at %s", f.pkg.Fset.Position(method.Pos()))
+		}
+	} else {
+		named := obj.Type().(*types.Named)
+		generator.TypeParams = typeParams(named)
+		for i := 0; i < named.TypeParams().Len(); i++ {
+			constraint := f.typeString(generator, named.TypeParams().At(i).Constraint())
+			generator.TypeConstraints = append(generator.TypeConstraints, constraint)
 		}
 	}
 
-	generator.FileName = f.curFileName
+	if err := f.locate(generator); err != nil {
+		return err
+	}
+	generator.Type = spec.Name.Name
 	generator.GeneratorType = GeneratorTypeStructure
-	fields, err := f.parseFields(structType.Fields.List)
+	generator.DefaultFunc, generator.DefaultPointer = f.defaultFunc(spec.Name.Name, obj.Type())
+	fields, err := f.parseFields(generator, obj.Type())
 	if err != nil {
-		return fmt.Errorf("f.parseFields: %w", err)
+		return fmt.Errorf("can't parse the fields of '%s': %w", spec.Name.Name, err)
 	}
-
 	generator.Fields = fields
 	return nil
 }
 
-func (f *generatorFactory) parseFields(fieldList []*ast.Field) ([]Field, error) {
+// parseFields returns the fields of t whose underlying type is a struct,
+// followed by the fields promoted from its embedded structs.
+func (f *generatorFactory) parseFields(g *Generator, t types.Type) ([]Field, error) {
+	structType := t.Underlying().(*types.Struct)
+	fields := make([]Field, 0, structType.NumFields())
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if !f.isAccessible(field) {
+			debug.Printf("skip inaccessible field %s of package %s", field.Name(), field.Pkg().Path())
+			continue
+		}
+		parsed, err := f.newField(g, field, structType.Tag(i), false)
+		if err != nil {
+			return nil, err
+		}
+		debug.Printf("parse field name: %s, type: %s, embedded: %t, config: %+v", parsed.Name, parsed.Type, parsed.Embedded, parsed.Config)
+		fields = append(fields, parsed)
+	}
+
+	promotedFields, err := f.parsePromotedFields(g, t)
+	if err != nil {
+		return nil, err
+	}
+	return append(fields, promotedFields...), nil
+}
+
+// parsePromotedFields returns the fields promoted from the embedded structs of
+// t. The promotion follows the rules of selectors: a field of a shallower
+// depth shadows the deeper ones, and the fields with the same name at the
+// same depth are ambiguous, so they are not promoted.
+func (f *generatorFactory) parsePromotedFields(g *Generator, t types.Type) ([]Field, error) {
 	var fields []Field
-	for _, field := range fieldList {
-		if len(field.Names) == 0 {
+	for _, name := range embeddedFieldNames(t) {
+		obj, index, indirect := types.LookupFieldOrMethod(t, true, f.pkg.Types, name)
+		field, ok := obj.(*types.Var)
+		if !ok || !field.IsField() || len(index) < 2 {
+			debug.Printf("field %s is not promoted", name)
 			continue
 		}
 
-		typeStr, err := parseNode(f.curFset, field.Type)
+		parsed, err := f.newField(g, field, fieldTag(t, index), true)
 		if err != nil {
-			return nil, fmt.Errorf("parseNode: %w", err)
+			return nil, err
+		}
+		parsed.Indirect = indirect
+		parsed.Pointers = embeddedPointers(t, index)
+		debug.Printf("parse promoted field name: %s, type: %s, depth: %d, config: %+v", parsed.Name, parsed.Type, len(index)-1, parsed.Config)
+		fields = append(fields, parsed)
+	}
+	return fields, nil
+}
+
+// newField returns the Field of the struct field with the tag, which is
+// shared by the declared fields and the promoted ones.
+func (f *generatorFactory) newField(g *Generator, field *types.Var, tag string, promoted bool) (Field, error) {
+	config, err := f.parseFieldConfig(field, tag)
+	if err != nil {
+		return Field{}, err
+	}
+	copyKind, elemPointer := copyKind(field.Type())
+	return Field{
+		Name:          field.Name(),
+		Type:          f.typeString(g, field.Type()),
+		Zero:          f.zeroValue(g, field.Type()),
+		Embedded:      field.Embedded(),
+		Promoted:      promoted,
+		CopyKind:      copyKind,
+		ElemPointer:   elemPointer,
+		LockKind:      lockKind(field.Type()),
+		Config:        config,
+		ValidateTag:   reflect.StructTag(tag).Get("validate"),
+		ValidateKind:  validationKind(field.Type()),
+		ValidateBasic: validationBasic(field.Type()),
+	}, nil
+}
+
+// fieldTag returns the tag of the field selected from t through the index
+// sequence of embedded fields.
+func fieldTag(t types.Type, index []int) string {
+	structType := deref(t).Underlying().(*types.Struct)
+	for _, i := range index[:len(index)-1] {
+		structType = deref(structType.Field(i).Type()).Underlying().(*types.Struct)
+	}
+	return structType.Tag(index[len(index)-1])
+}
+
+// embeddedPointers returns the selectors of the embedded pointers on the path
+// to the field selected from t through the index sequence of embedded fields.
+func embeddedPointers(t types.Type, index []int) []string {
+	var pointers []string
+	var path []string
+	structType := deref(t).Underlying().(*types.Struct)
+	for _, i := range index[:len(index)-1] {
+		field := structType.Field(i)
+		path = append(path, field.Name())
+		if _, ok := field.Type().Underlying().(*types.Pointer); ok {
+			pointers = append(pointers, strings.Join(path, "."))
 		}
+		structType = deref(field.Type()).Underlying().(*types.Struct)
+	}
+	return pointers
+}
 
-		for _, name := range field.Names {
-			debug.Printf("parse field name: %s, type: %s", name, typeStr)
-			fields = append(fields, Field{name.Name, typeStr})
+// parseFieldConfig returns the configuration of the field from either its
+// struct tag or its directives, which can't be used together. Both of them
+// only decide the accessors of the fields selected by the options, except
+// that a skipped field is never generated.
+func (f *generatorFactory) parseFieldConfig(field *types.Var, structTag string) (*FieldConfig, error) {
+	pos := f.pkg.Fset.Position(field.Pos())
+	tagConfig, err := parseFieldTag(structTag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: field %s: %w", pos, field.Name(), err)
+	}
+
+	var directiveConfig *FieldConfig
+	if node := f.fieldNode(field); node != nil {
+		directiveConfig, err = parseFieldDirectives(f.pkg.Fset, node.Doc, node.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name(), err)
 		}
 	}
-	return fields, nil
+
+	if tagConfig != nil && directiveConfig != nil {
+		return nil, fmt.Errorf("%s: field %s has both the accessor tag and goaccessor directives", pos, field.Name())
+	}
+	config := directiveConfig
+	if tagConfig != nil {
+		config = tagConfig
+	}
+	if config != nil && config.Default != "" {
+		config.Default, err = defaultValue(config.Default, field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%s: field %s: %w", pos, field.Name(), err)
+		}
+	}
+	return config, nil
+}
+
+// defaultValue returns the expression of the default value of a field of type
+// t. The value of a string field is quoted unless it's a string literal, and
+// the values of the others are Go expressions.
+func defaultValue(value string, t types.Type) (string, error) {
+	basic, ok := t.Underlying().(*types.Basic)
+	if ok && basic.Info()&types.IsString != 0 && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "`") {
+		value = strconv.Quote(value)
+	}
+	if _, err := parser.ParseExpr(value); err != nil {
+		return "", fmt.Errorf("invalid default value %s: %w", value, err)
+	}
+	return value, nil
+}
+
+// parseFieldTag parses the accessor key of the struct tag, and returns nil if
+// there is no such key. The value is a comma-separated list of "get", "set",
+// "readonly", "name=...", "unexported", "lock=...", "clone", "required",
+// "optional", and "default=...", or a single "-".
+func parseFieldTag(structTag string) (*FieldConfig, error) {
+	value, ok := reflect.StructTag(structTag).Lookup("accessor")
+	if !ok || value == "" {
+		return nil, nil
+	}
+	if value == "-" {
+		return &FieldConfig{Skip: true}, nil
+	}
+
+	config := &FieldConfig{}
+	readonly := false
+	for _, option := range strings.Split(value, ",") {
+		switch option = strings.TrimSpace(option); {
+		case option == "get":
+			config.Getter = true
+		case option == "set":
+			config.Setter = true
+		case option == "readonly":
+			readonly = true
+			config.Getter = true
+		case option == "unexported":
+			config.UnexportedGetter, config.UnexportedSetter = true, true
+		case option == "clone":
+			config.Clone = true
+		case option == "required":
+			config.Required = true
+		case option == "optional":
+			config.Optional = true
+		case strings.HasPrefix(option, "default="):
+			config.Default = strings.TrimPrefix(option, "default=")
+			if config.Default == "" {
+				return nil, fmt.Errorf("empty default value in tag %q", value)
+			}
+		case strings.HasPrefix(option, "lock="):
+			config.Lock = strings.TrimPrefix(option, "lock=")
+			if !token.IsIdentifier(config.Lock) {
+				return nil, fmt.Errorf("invalid lock %q in tag %q", config.Lock, value)
+			}
+		case strings.HasPrefix(option, "name="):
+			name := strings.TrimPrefix(option, "name=")
+			if !token.IsIdentifier(name) {
+				return nil, fmt.Errorf("invalid accessor name %q in tag %q", name, value)
+			}
+			config.GetterName, config.SetterName = name, name
+		default:
+			return nil, fmt.Errorf("unknown option %q in accessor tag %q", option, value)
+		}
+	}
+	if readonly && config.Setter {
+		return nil, fmt.Errorf("a readonly field can't have a setter in accessor tag %q", value)
+	}
+	if config.Required && config.Optional {
+		return nil, fmt.Errorf("a field can't be both required and optional in accessor tag %q", value)
+	}
+	return config, nil
+}
+
+// parseFieldDirectives parses the goaccessor directives in the comments of a
+// field, and returns nil if there is none. The directives are
+//
+//	//goaccessor:getter [name=...] [unexported]
+//	//goaccessor:setter [name=...] [unexported]
+//	//goaccessor:lock field
+//	//goaccessor:clone
+//	//goaccessor:required
+//	//goaccessor:optional
+//	//goaccessor:default value
+//	//goaccessor:skip
+//
+// where the name is used in the accessor instead of the field name,
+// unexported makes the accessor unexported, the lock field guards the
+// accessors of the field, clone makes the field cloned by the Clone
+// methods of its values in copy mode, required makes the field required by
+// the builder, optional leaves the field out of the parameters of the
+// constructor, and the value is the default value of the field in the
+// constructors.
+func parseFieldDirectives(fset *token.FileSet, groups ...*ast.CommentGroup) (*FieldConfig, error) {
+	var config *FieldConfig
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			text, ok := strings.CutPrefix(c.Text, "//goaccessor:")
+			if !ok {
+				continue
+			}
+			pos := fset.Position(c.Slash)
+			if config == nil {
+				config = &FieldConfig{}
+			}
+
+			words := strings.Fields(text)
+			if len(words) == 0 {
+				return nil, fmt.Errorf("%s: empty directive %s", pos, c.Text)
+			}
+			switch words[0] {
+			case "skip", "clone", "required", "optional":
+				if len(words) > 1 {
+					return nil, fmt.Errorf("%s: unexpected options %s in directive %s", pos, words[1:], c.Text)
+				}
+				switch words[0] {
+				case "skip":
+					config.Skip = true
+				case "clone":
+					config.Clone = true
+				case "required":
+					config.Required = true
+				default:
+					config.Optional = true
+				}
+			case "default":
+				value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "default"))
+				if value == "" {
+					return nil, fmt.Errorf("%s: expected a default value in directive %s", pos, c.Text)
+				}
+				config.Default = value
+			case "lock":
+				if len(words) != 2 || !token.IsIdentifier(words[1]) {
+					return nil, fmt.Errorf("%s: expected a lock field in directive %s", pos, c.Text)
+				}
+				config.Lock = words[1]
+			case "getter", "setter":
+				var name string
+				var unexported bool
+				for _, option := range words[1:] {
+					switch {
+					case option == "unexported":
+						unexported = true
+					case strings.HasPrefix(option, "name="):
+						name = strings.TrimPrefix(option, "name=")
+						if !token.IsIdentifier(name) {
+							return nil, fmt.Errorf("%s: invalid accessor name %q in directive %s", pos, name, c.Text)
+						}
+					default:
+						return nil, fmt.Errorf("%s: unknown option %q in directive %s", pos, option, c.Text)
+					}
+				}
+				if words[0] == "getter" {
+					config.Getter, config.GetterName, config.UnexportedGetter = true, name, unexported
+				} else {
+					config.Setter, config.SetterName, config.UnexportedSetter = true, name, unexported
+				}
+			default:
+				return nil, fmt.Errorf("%s: unknown directive %s", pos, c.Text)
+			}
+			if config.Skip && (config.Getter || config.Setter || config.Lock != "" || config.Clone || config.Required || config.Default != "") {
+				return nil, fmt.Errorf("%s: a skipped field can't have accessors", pos)
+			}
+			if config.Required && config.Optional {
+				return nil, fmt.Errorf("%s: a field can't be both required and optional", pos)
+			}
+		}
+	}
+	return config, nil
+}
+
+// fieldNode returns the syntax node of the struct field, or nil if it is
+// unavailable. The nodes of all loaded packages are indexed by the positions
+// of their names when it is called the first time.
+func (f *generatorFactory) fieldNode(field *types.Var) *ast.Field {
+	if f.fieldNodes == nil {
+		f.fieldNodes = make(map[token.Pos]*ast.Field)
+		packages.Visit(f.pkgs, nil, func(pkg *packages.Package) {
+			for _, file := range pkg.Syntax {
+				ast.Inspect(file, func(n ast.Node) bool {
+					structType, ok := n.(*ast.StructType)
+					if !ok {
+						return true
+					}
+					for _, node := range structType.Fields.List {
+						for _, name := range node.Names {
+							f.fieldNodes[name.Pos()] = node
+						}
+						if ident := embeddedFieldIdent(node.Type); len(node.Names) == 0 && ident != nil {
+							f.fieldNodes[ident.Pos()] = node
+						}
+					}
+					return true
+				})
+			}
+		})
+	}
+	return f.fieldNodes[field.Pos()]
+}
+
+// embeddedFieldIdent returns the identifier of the embedded field, whose
+// position is the position of the field, the same as go/types.
+func embeddedFieldIdent(e ast.Expr) *ast.Ident {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.StarExpr:
+		return embeddedFieldIdent(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel
+	case *ast.IndexExpr:
+		return embeddedFieldIdent(e.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldIdent(e.X)
+	}
+	return nil
 }
 
 func (f *generatorFactory) inspectFunctionDeclaration(decl *ast.FuncDecl) error {
@@ -504,12 +801,41 @@ func (f *generatorFactory) inspectFunctionDeclaration(decl *ast.FuncDecl) error
 		return nil
 	}
 
-	if len(decl.Recv.List) != 1 {
-		return fmt.Errorf("expected one receiver, got %d", len(decl.Recv.List))
+	recvTypeName, err := receiverTypeName(decl.Recv)
+	if err != nil {
+		return err
 	}
 	receiver := decl.Recv.List[0]
 
-	t := receiver.Type
+	generator, ok := f.generators[recvTypeName]
+	if !ok {
+		return nil
+	}
+
+	if names := receiver.Names; len(names) > 0 {
+		generator.ReceiverName = names[0].Name
+	}
+	generator.Methods[decl.Name.Name] = fmt.Sprintf("method declared at %s", f.pkg.Fset.Position(decl.Name.Pos()))
+
+	if fn, ok := f.pkg.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+		if h, ok := hook(decl.Name.Name, fn.Type().(*types.Signature)); ok {
+			debug.Printf("%s is the %s hook of field %s", decl.Name.Name, h.Kind, h.Field)
+			if generator.Hooks == nil {
+				generator.Hooks = make(map[string]Hook)
+			}
+			generator.Hooks[decl.Name.Name] = h
+		}
+	}
+	return nil
+}
+
+// receiverTypeName returns the name of the receiver base type of a method.
+func receiverTypeName(recv *ast.FieldList) (string, error) {
+	if len(recv.List) != 1 {
+		return "", fmt.Errorf("expected one receiver, got %d", len(recv.List))
+	}
+
+	t := recv.List[0].Type
 	// handler pointer
 	if starExpr, ok := t.(*ast.StarExpr); ok {
 		debug.Printf("inspect t as *ast.StarExpr\n")
@@ -526,51 +852,518 @@ func (f *generatorFactory) inspectFunctionDeclaration(decl *ast.FuncDecl) error
 	}
 	ident, ok := t.(*ast.Ident)
 	if !ok {
-		return fmt.Errorf("unexpected receiver type: %T", t)
+		return "", fmt.Errorf("unexpected receiver type: %T", t)
 	}
-	recvTypeName := ident.Name
+	return ident.Name, nil
+}
 
-	generator, ok := f.generators[recvTypeName]
+// inspectDeclared records the declarations the generated names may conflict
+// with. The functions conflict with the package scope, and the declarations
+// of the files generated for other targets, which are dropped by parseFile,
+// are parsed again. The generators of a package share the functions they
+// generate.
+func (f *generatorFactory) inspectDeclared() error {
+	fset := token.NewFileSet()
+	generatedFiles := make(map[string]*ast.File)
+	generatedFuncs := make(map[*packages.Package]map[string]string)
+	for name, generator := range f.generators {
+		pkg, ok := f.targetPkgs[name]
+		if !ok {
+			// the target isn't found, which is reported by Generate
+			continue
+		}
+		f.pkg = pkg
+
+		if _, ok := generatedFuncs[pkg]; !ok {
+			generatedFuncs[pkg] = make(map[string]string)
+		}
+		generator.Generated = generatedFuncs[pkg]
+
+		scope := pkg.Types.Scope()
+		generator.Declared = make(map[string]string, scope.Len())
+		generator.Locks = make(map[string]string)
+		for _, declName := range scope.Names() {
+			obj := scope.Lookup(declName)
+			generator.Declared[declName] = fmt.Sprintf("%s declared at %s", objectKind(obj), pkg.Fset.Position(obj.Pos()))
+			if v, ok := obj.(*types.Var); ok && lockKind(v.Type()) != "" {
+				generator.Locks[declName] = lockKind(v.Type())
+			}
+		}
+
+		filePath, err := filepath.Abs(generator.FilePath())
+		if err != nil {
+			return fmt.Errorf("filepath.Abs: %w", err)
+		}
+		for _, file := range pkg.Syntax {
+			path := pkg.Fset.Position(file.Package).Filename
+			if !isGeneratedFile(path) || path == filePath {
+				continue
+			}
+			generatedFile, ok := generatedFiles[path]
+			if !ok {
+				generatedFile, err = parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+				if err != nil {
+					return fmt.Errorf("parser.ParseFile: %w", err)
+				}
+				generatedFiles[path] = generatedFile
+			}
+			if err := f.inspectGeneratedFile(generator, fset, generatedFile); err != nil {
+				return fmt.Errorf("inspect generated file %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// inspectGeneratedFile records the functions and types of the generated file
+// for all targets, and the methods of the generated file for the struct types.
+func (f *generatorFactory) inspectGeneratedFile(g *Generator, fset *token.FileSet, file *ast.File) error {
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+			for _, spec := range genDecl.Specs {
+				name := spec.(*ast.TypeSpec).Name
+				g.Declared[name.Name] = fmt.Sprintf("type declared at %s", fset.Position(name.Pos()))
+			}
+			continue
+		}
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		pos := fset.Position(decl.Name.Pos())
+
+		if decl.Recv == nil {
+			g.Declared[decl.Name.Name] = fmt.Sprintf("function declared at %s", pos)
+			continue
+		}
+		if g.GeneratorType != GeneratorTypeStructure {
+			continue
+		}
+
+		recvTypeName, err := receiverTypeName(decl.Recv)
+		if err != nil {
+			return err
+		}
+		// the receiver may be an alias of the target
+		scope := f.pkg.Types.Scope()
+		recv, target := scope.Lookup(recvTypeName), scope.Lookup(g.Name)
+		if recv != nil && target != nil && types.Unalias(recv.Type()) == types.Unalias(target.Type()) {
+			g.Methods[decl.Name.Name] = fmt.Sprintf("method declared at %s", pos)
+		}
+	}
+	return nil
+}
+
+// variableUses returns the positions where the package level variable v is
+// used in the packages, apart from the generated files. The function bodies
+// are dropped by parseFile, so the files are type checked again with them.
+func (f *generatorFactory) variableUses(v *types.Var) ([]string, error) {
+	var positions []token.Position
+	for _, pkg := range f.pkgs {
+		info, err := f.bodyInfo(pkg)
+		if err != nil {
+			return nil, err
+		}
+		for id, obj := range info.Uses {
+			// v is checked again along with its package, so it's matched by
+			// its package and name
+			if obj.Pkg() != nil && obj.Pkg().Path() == v.Pkg().Path() && obj.Parent() == obj.Pkg().Scope() && obj.Name() == v.Name() {
+				positions = append(positions, pkg.Fset.Position(id.Pos()))
+			}
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Filename != positions[j].Filename {
+			return positions[i].Filename < positions[j].Filename
+		}
+		return positions[i].Offset < positions[j].Offset
+	})
+
+	uses := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		uses = append(uses, pos.String())
+	}
+	return uses, nil
+}
+
+// bodyInfo returns the uses of the objects in the files of the package,
+// including the function bodies. The declarations of the generated files are
+// left out, so the type errors are ignored.
+func (f *generatorFactory) bodyInfo(pkg *packages.Package) (*types.Info, error) {
+	if info, ok := f.bodyInfos[pkg]; ok {
+		return info, nil
+	}
+
+	var files []*ast.File
+	for _, path := range pkg.GoFiles {
+		if isGeneratedFile(path) {
+			continue
+		}
+		file, err := parser.ParseFile(pkg.Fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parser.ParseFile: %w", err)
+		}
+		files = append(files, file)
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	config := types.Config{
+		Importer:    packageImporter(pkg.Imports),
+		FakeImportC: true,
+		Error:       func(err error) { debug.Printf("ignore type error: %s\n", err) },
+	}
+	_, _ = config.Check(pkg.PkgPath, pkg.Fset, files, info)
+
+	if f.bodyInfos == nil {
+		f.bodyInfos = make(map[*packages.Package]*types.Info)
+	}
+	f.bodyInfos[pkg] = info
+	return info, nil
+}
+
+// packageImporter imports the loaded packages by their import paths.
+type packageImporter map[string]*packages.Package
+
+func (imports packageImporter) Import(path string) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	pkg, ok := imports[path]
+	if !ok || pkg.Types == nil {
+		return nil, fmt.Errorf("package %s isn't loaded", path)
+	}
+	return pkg.Types, nil
+}
+
+// atomicType returns the sync/atomic type which can store the values of t, and
+// the type the values are converted to for it, which is empty if they aren't
+// converted. The integers are widened to 64 bits except for the types of the
+// same size, the pointers are stored in atomic.Pointer, and the interfaces are
+// stored in atomic.Value. It returns "" if there is no such type.
+func (f *generatorFactory) atomicType(g *Generator, t types.Type) (string, string) {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		return "Pointer[" + f.typeString(g, ptr.Elem()) + "]", ""
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Interface:
+		return "Value", ""
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool:
+			return "Bool", "bool"
+		case types.Int32:
+			return "Int32", "int32"
+		case types.Uint32:
+			return "Uint32", "uint32"
+		case types.Uintptr:
+			return "Uintptr", "uintptr"
+		case types.Int, types.Int8, types.Int16, types.Int64:
+			return "Int64", "int64"
+		case types.Uint, types.Uint8, types.Uint16, types.Uint64:
+			return "Uint64", "uint64"
+		}
+	}
+	return "", ""
+}
+
+// zeroValue returns the expression of the zero value of t.
+func (f *generatorFactory) zeroValue(g *Generator, t types.Type) string {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + f.typeString(g, t) + ")"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		case u.Kind() == types.UnsafePointer:
+			return "nil"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil"
+	case *types.Struct, *types.Array:
+		return f.typeString(g, t) + "{}"
+	}
+	return "*new(" + f.typeString(g, t) + ")"
+}
+
+// defaultFunc returns the function named like defaultBook which returns the
+// default value of the struct type, or "" if there is no such function, and
+// whether it returns a pointer. A generic function has the same type
+// parameters as the struct type.
+func (f *generatorFactory) defaultFunc(name string, t types.Type) (string, bool) {
+	named, ok := types.Unalias(t).(*types.Named)
 	if !ok {
+		return "", false
+	}
+	funcName := "default" + upper(name)
+	fn, ok := f.pkg.Types.Scope().Lookup(funcName).(*types.Func)
+	if !ok {
+		return "", false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 || sig.TypeParams().Len() != named.TypeParams().Len() {
+		debug.Printf("%s doesn't return the default value of %s", funcName, name)
+		return "", false
+	}
+
+	result, pointer := sig.Results().At(0).Type(), false
+	if ptr, ok := types.Unalias(result).(*types.Pointer); ok {
+		result, pointer = ptr.Elem(), true
+	}
+	if resultNamed, ok := types.Unalias(result).(*types.Named); !ok || resultNamed.Origin() != named.Origin() {
+		debug.Printf("%s doesn't return the default value of %s", funcName, name)
+		return "", false
+	}
+	return funcName, pointer
+}
+
+// copyKind returns the kind of t if it's a slice, map, or array, and whether
+// its elements are pointers, or t itself is a pointer if it has no elements.
+func copyKind(t types.Type) (kind string, elemPointer bool) {
+	isPointer := func(t types.Type) bool {
+		_, ok := t.Underlying().(*types.Pointer)
+		return ok
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return "slice", isPointer(u.Elem())
+	case *types.Map:
+		return "map", isPointer(u.Elem())
+	case *types.Array:
+		return "array", isPointer(u.Elem())
+	}
+	return "", isPointer(t)
+}
+
+// lockKind returns the name of the type if t is sync.Mutex or sync.RWMutex, or
+// a pointer to them, or "" otherwise.
+func lockKind(t types.Type) string {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "sync" {
+		return ""
+	}
+	switch name := named.Obj().Name(); name {
+	case "Mutex", "RWMutex":
+		return name
+	}
+	return ""
+}
+
+// objectKind describes the kind of the package level object.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.TypeName:
+		return "type"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	default:
+		return "object"
+	}
+}
+
+// parseFile is used by packages.Load to parse the source files. The generated
+// files only keep their package clauses so their declarations won't be seen,
+// and the function bodies are dropped to speed up type checking, as only the
+// package level declarations are inspected.
+func parseFile(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	if isGeneratedFile(filename) {
+		return parser.ParseFile(fset, filename, src, parser.PackageClauseOnly)
+	}
+
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok {
+			decl.Body = nil
+		}
+	}
+	return file, nil
+}
+
+// isAccessible reports whether the object can be referred in the current
+// package, unexported objects of other packages can't.
+func (f *generatorFactory) isAccessible(obj types.Object) bool {
+	return obj.Exported() || obj.Pkg() == f.pkg.Types
+}
+
+// typeString prints t relative to the current package, and records the
+// imports of the packages it refers to in g. Types of dot-imported packages
+// are qualified as well, and the packages whose names are declared in the
+// current package are renamed.
+func (f *generatorFactory) typeString(g *Generator, t types.Type) string {
+	declared := func(name string) bool {
+		return f.pkg.Types.Scope().Lookup(name) != nil
+	}
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == f.pkg.Types {
+			return ""
+		}
+		return g.AddImport(pkg.Name(), pkg.Path(), declared)
+	})
+}
+
+// checkResolved reports an error if t, the type of the object named name at
+// pos, can't be resolved. The type errors in the declaration are included.
+func (f *generatorFactory) checkResolved(name string, t types.Type, pos token.Pos) error {
+	if !isInvalid(t) {
 		return nil
 	}
 
-	if names := receiver.Names; len(names) > 0 {
-		generator.ReceiverName = names[0].Name
+	start, end := pos, pos
+	for _, file := range f.pkg.Syntax {
+		if file.Pos() > pos || pos >= file.End() {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+		for _, node := range path {
+			if _, ok := node.(ast.Spec); ok {
+				start, end = node.Pos(), node.End()
+				break
+			}
+		}
 	}
-	generator.Methods[decl.Name.Name] = struct{}{}
 
-	return nil
+	var msgs []string
+	for _, err := range f.pkg.TypeErrors {
+		if start <= err.Pos && err.Pos < end {
+			msgs = append(msgs, err.Msg)
+		}
+	}
+	return fmt.Errorf("can't resolve the type of '%s' at %s: %s", name, f.pkg.Fset.Position(pos), strings.Join(msgs, "; "))
 }
 
 // help functions
 
-func parseTypeArguments(fset *token.FileSet, expr ast.Expr) ([]string, error) {
-	args := make([]string, 0)
-	if expr, ok := expr.(*ast.IndexExpr); ok {
-		arg, err := parseNode(fset, expr.Index)
-		if err != nil {
-			return nil, fmt.Errorf("parseNode: %w", err)
+// embeddedFieldNames returns the names of the fields of the structs embedded
+// in t, directly or indirectly.
+func embeddedFieldNames(t types.Type) []string {
+	var names []string
+	seenNames := make(map[string]struct{})
+	seenTypes := make(map[string]struct{})
+	queue := embeddedTypes(t)
+	for len(queue) > 0 {
+		embedded := queue[0]
+		queue = queue[1:]
+
+		key := types.TypeString(embedded, nil)
+		if _, ok := seenTypes[key]; ok {
+			continue
+		}
+		seenTypes[key] = struct{}{}
+
+		structType, ok := embedded.Underlying().(*types.Struct)
+		if !ok {
+			continue
 		}
-		args = append(args, arg)
+		for i := 0; i < structType.NumFields(); i++ {
+			name := structType.Field(i).Name()
+			if _, ok := seenNames[name]; !ok {
+				seenNames[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+		queue = append(queue, embeddedTypes(embedded)...)
 	}
-	if expr, ok := expr.(*ast.IndexListExpr); ok {
-		for _, index := range expr.Indices {
-			arg, err := parseNode(fset, index)
-			if err != nil {
-				return nil, fmt.Errorf("parseNode: %w", err)
+	return names
+}
+
+// embeddedTypes returns the types of the embedded fields of t.
+func embeddedTypes(t types.Type) []types.Type {
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	var result []types.Type
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if !field.Embedded() {
+			continue
+		}
+		result = append(result, deref(field.Type()))
+	}
+	return result
+}
+
+// isInvalid reports whether t or any type it is composed of is invalid, the
+// underlying types of named types are not checked.
+func isInvalid(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() == types.Invalid
+	case *types.Pointer:
+		return isInvalid(t.Elem())
+	case *types.Slice:
+		return isInvalid(t.Elem())
+	case *types.Array:
+		return isInvalid(t.Elem())
+	case *types.Chan:
+		return isInvalid(t.Elem())
+	case *types.Map:
+		return isInvalid(t.Key()) || isInvalid(t.Elem())
+	case *types.Signature:
+		return isInvalid(t.Params()) || isInvalid(t.Results())
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if isInvalid(t.At(i).Type()) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if isInvalid(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if isInvalid(t.ExplicitMethod(i).Type()) {
+				return true
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if isInvalid(t.EmbeddedType(i)) {
+				return true
 			}
-			args = append(args, arg)
 		}
+	case *types.Named:
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if isInvalid(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return isInvalid(types.Unalias(t))
 	}
-	return args, nil
+	return false
 }
 
-func parseNode(fset *token.FileSet, node any) (string, error) {
-	var buf bytes.Buffer
-	err := printer.Fprint(&buf, token.NewFileSet(), node)
-	if err != nil {
-		return "", fmt.Errorf("printer.Fprint: %w", err)
+// deref returns the type t points to if t is a pointer, aliases are resolved.
+func deref(t types.Type) types.Type {
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		return types.Unalias(ptr.Elem())
+	}
+	return t
+}
+
+func typeParams(named *types.Named) []string {
+	var params []string
+	for i := 0; i < named.TypeParams().Len(); i++ {
+		params = append(params, named.TypeParams().At(i).Obj().Name())
 	}
-	return buf.String(), nil
+	return params
 }
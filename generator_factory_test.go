@@ -0,0 +1,270 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writePackage writes files into a temporary module and returns its directory.
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+	setupLogger()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/test\n\ngo 1.22\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("os.WriteFile %s: %s", name, err.Error())
+		}
+	}
+	return dir
+}
+
+func TestNewGeneratorsErrors(t *testing.T) {
+	type testCase struct {
+		src    string
+		target string
+		errs   []string
+	}
+	for _, tc := range []testCase{
+		{src: "const Huge = 1 << 100\n", target: "Huge", errs: []string{"overflows int"}},
+		{src: "import \"time\"\n\ntype Time = time.Time\n", target: "Time", errs: []string{"can't define methods on 'Time'"}},
+		{src: "var x = undefined.New()\n", target: "x", errs: []string{"can't resolve the type of 'x'", "undefined: undefined"}},
+		{src: "type S struct {\n\tf Unknown\n}\n", target: "S", errs: []string{"can't resolve the type of 'S'", "undefined: Unknown"}},
+	} {
+		dir := writePackage(t, map[string]string{
+			"s.go": "package test\n\n" + tc.src,
+		})
+		_, err := NewGenerators([]string{tc.target}, dir, false, buildConfig{})
+		for _, e := range tc.errs {
+			if err == nil || !strings.Contains(err.Error(), e) {
+				t.Errorf("expected error %s for %q, got %v", e, tc.src, err)
+			}
+		}
+	}
+}
+
+func TestParseFieldTag(t *testing.T) {
+	type testCase struct {
+		structTag string
+		config    *FieldConfig
+		err       string
+	}
+	for _, tc := range []testCase{
+		{structTag: `json:"name"`},
+		{structTag: `accessor:""`},
+		{structTag: `accessor:"-"`, config: &FieldConfig{Skip: true}},
+		{structTag: `json:"id" accessor:"get,name=ID"`, config: &FieldConfig{Getter: true, GetterName: "ID", SetterName: "ID"}},
+		{structTag: `accessor:"readonly, unexported"`, config: &FieldConfig{Getter: true, UnexportedGetter: true, UnexportedSetter: true}},
+		{structTag: `accessor:"get,set"`, config: &FieldConfig{Getter: true, Setter: true}},
+		{structTag: `accessor:"get,lock=mu"`, config: &FieldConfig{Getter: true, Lock: "mu"}},
+		{structTag: `accessor:"lock=m.u"`, err: "invalid lock"},
+		{structTag: `accessor:"get,clone"`, config: &FieldConfig{Getter: true, Clone: true}},
+		{structTag: `accessor:"required"`, config: &FieldConfig{Required: true}},
+		{structTag: `accessor:"default=1 << 10"`, config: &FieldConfig{Default: "1 << 10"}},
+		{structTag: `accessor:"default="`, err: "empty default value"},
+		{structTag: `accessor:"optional,default=1"`, config: &FieldConfig{Optional: true, Default: "1"}},
+		{structTag: `accessor:"required,optional"`, err: "both required and optional"},
+		{structTag: `accessor:"readonly,set"`, err: "readonly"},
+		{structTag: `accessor:"name=1D"`, err: "invalid accessor name"},
+		{structTag: `accessor:"getter"`, err: "unknown option"},
+	} {
+		config, err := parseFieldTag(tc.structTag)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error %s for %s, got %v", tc.err, tc.structTag, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.structTag, err.Error())
+		} else if !reflect.DeepEqual(config, tc.config) {
+			t.Errorf("got %+v for %s, expected %+v", config, tc.structTag, tc.config)
+		}
+	}
+}
+
+func TestNewGeneratorsFieldDirectives(t *testing.T) {
+	type testCase struct {
+		field  string
+		config *FieldConfig
+		err    string
+	}
+	for _, tc := range []testCase{
+		{field: "\tName string // a name"},
+		{field: "\t//goaccessor:skip\n\tName string", config: &FieldConfig{Skip: true}},
+		{field: "\tName string //goaccessor:getter name=Title", config: &FieldConfig{Getter: true, GetterName: "Title"}},
+		{field: "\t//goaccessor:getter unexported\n\t//goaccessor:setter name=Rename\n\tName string", config: &FieldConfig{Getter: true, Setter: true, SetterName: "Rename", UnexportedGetter: true}},
+		{field: "\t//goaccessor:getter\n\t*Embedded", config: &FieldConfig{Getter: true}},
+		{field: "\t//goaccessor:getter name=1st\n\tName string", err: "s.go:4:2: invalid accessor name"},
+		{field: "\tName string //goaccessor:getter readonly", err: "s.go:4:14: unknown option"},
+		{field: "\t//goaccessor:hidden\n\tName string", err: "s.go:4:2: unknown directive"},
+		{field: "\tName string //goaccessor:lock mu", config: &FieldConfig{Lock: "mu"}},
+		{field: "\t//goaccessor:lock\n\tName string", err: "s.go:4:2: expected a lock field"},
+		{field: "\t//goaccessor:clone\n\tNames []string", config: &FieldConfig{Clone: true}},
+		{field: "\t//goaccessor:clone deep\n\tNames []string", err: "s.go:4:2: unexpected options"},
+		{field: "\t//goaccessor:default hello, world\n\tName string", config: &FieldConfig{Default: `"hello, world"`}},
+		{field: "\t//goaccessor:default []string{\"a\", \"b\"}\n\tNames []string", config: &FieldConfig{Default: `[]string{"a", "b"}`}},
+		{field: "\t//goaccessor:default 1 +\n\tSize int", err: "invalid default value"},
+		{field: "\t//goaccessor:default\n\tName string", err: "s.go:4:2: expected a default value"},
+		{field: "\t//goaccessor:optional\n\tName string", config: &FieldConfig{Optional: true}},
+		{field: "\t//goaccessor:optional\n\t//goaccessor:required\n\tName string", err: "s.go:5:2: a field can't be both required and optional"},
+		{field: "\t//goaccessor:skip\n\t//goaccessor:getter\n\tName string", err: "s.go:5:2: a skipped field can't have accessors"},
+		{field: "\t//goaccessor:getter\n\tName string `accessor:\"get\"`", err: "has both the accessor tag and goaccessor directives"},
+	} {
+		dir := writePackage(t, map[string]string{
+			"s.go": "package test\n\ntype S struct {\n" + tc.field + "\n}\n\ntype Embedded struct{}\n",
+		})
+		generators, err := NewGenerators([]string{"S"}, dir, false, buildConfig{})
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error %s for %q, got %v", tc.err, tc.field, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %q: %s", tc.field, err.Error())
+			continue
+		}
+		if config := generators[0].Fields[0].Config; !reflect.DeepEqual(config, tc.config) {
+			t.Errorf("got %+v for %q, expected %+v", config, tc.field, tc.config)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	type testCase struct {
+		src     string
+		files   map[string]string
+		target  string
+		options []optionsFn
+		errs    []string
+	}
+	for _, tc := range []testCase{
+		// conflicts
+		{src: "func GetConfig() string { return \"\" }\n\nvar config string\n", target: "config", options: []optionsFn{WithConflict(ConflictFail)}, errs: []string{"GetConfig of 'config' conflicts with the function declared at", "s.go:3:6"}},
+		// sync mode
+		{src: "import \"sync\"\n\ntype S struct {\n\ta, b sync.Mutex\n\tf int\n}\n", target: "S", options: []optionsFn{WithSync(true)}, errs: []string{"more than one sync.Mutex or sync.RWMutex field"}},
+		{src: "type S struct {\n\tf int\n}\n", target: "S", options: []optionsFn{WithSync(true)}, errs: []string{"no sync.Mutex or sync.RWMutex field"}},
+		{src: "type S struct {\n\tf int\n}\n", target: "S", options: []optionsFn{WithSync(true), WithLock("f")}, errs: []string{"lock f of 'S' isn't a sync.Mutex or sync.RWMutex"}},
+		{src: "import \"sync\"\n\ntype S struct {\n\ta, b sync.Mutex\n\tf int\n}\n", target: "S", options: []optionsFn{WithSync(true), WithLock("b")}},
+		{src: "import \"sync\"\n\ntype S struct {\n\tf int\n}\n\nvar mu sync.RWMutex\n", target: "S", options: []optionsFn{WithSync(true), WithLock("mu")}},
+		// atomic mode
+		{src: "const limit = 1\n", target: "limit", options: []optionsFn{WithAtomic(true)}, errs: []string{"can't store 'limit' atomically"}},
+		{src: "var name string\n", target: "name", options: []optionsFn{WithAtomic(true)}, errs: []string{"can't store 'name' atomically"}},
+		{src: "var hits int64\n\nfunc Hits() int64 {\n\treturn hits\n}\n", target: "hits", options: []optionsFn{WithAtomic(true)}, errs: []string{"can't store 'hits' atomically, which is used directly at ", "s.go:6:9"}},
+		{src: "var misses int64\n\nfunc Misses() int {\n\tmisses := 0\n\treturn misses\n}\n", target: "misses", options: []optionsFn{WithAtomic(true)}},
+		{src: "var Total int64\n", files: map[string]string{"x_test.go": "package test_test\n\nimport \"example.com/test\"\n\nvar _ = test.Total\n"}, target: "Total", options: []optionsFn{WithAtomic(true)}, errs: []string{"which is used directly at ", "x_test.go:5:14"}},
+		// withers
+		{src: "import \"sync\"\n\ntype S struct {\n\tmu   sync.Mutex\n\tName string\n}\n", target: "S", options: []optionsFn{WithWither(true)}, errs: []string{"lock field mu can't be copied"}},
+		// validate mode, the validate tags of other tools are left alone
+		// without it
+		{src: "type S struct {\n\tName string `validate:\"required,email\"`\n}\n", target: "S"},
+		{src: "type S struct {\n\tName string `validate:\"required,email\"`\n}\n", target: "S", options: []optionsFn{WithValidate(true)}, errs: []string{`unsupported option "required"`}},
+		{src: "type S struct {\n\tDone bool `validate:\"nonempty\"`\n}\n", target: "S", options: []optionsFn{WithValidate(true)}, errs: []string{"can't validate field Done of 'S', whose type is bool: unsupported type"}},
+		{src: "type S struct {\n\tName string\n}\n", target: "S", options: []optionsFn{WithValidate(true), WithChain(true)}, errs: []string{"can't chain the setters"}},
+		{src: "type S struct {\n\tCount int `validate:\"min=1.5\"`\n}\n", target: "S", options: []optionsFn{WithValidate(true)}, errs: []string{`can't validate field Count of 'S', whose type is int: invalid min in validate tag "min=1.5": 1.5 can't be represented by int`}},
+		{src: "type Size uint16\n\ntype S struct {\n\tSize Size `validate:\"min=-1\"`\n}\n", target: "S", options: []optionsFn{WithValidate(true)}, errs: []string{`can't validate field Size of 'S', whose type is Size: invalid min in validate tag "min=-1": -1 can't be represented by uint16`}},
+	} {
+		files := map[string]string{"s.go": "package test\n\n" + tc.src}
+		for name, content := range tc.files {
+			files[name] = content
+		}
+		dir := writePackage(t, files)
+		generators, err := NewGenerators([]string{tc.target}, dir, false, buildConfig{})
+		if err != nil {
+			t.Fatalf("NewGenerators %q: %s", tc.src, err.Error())
+		}
+		err = generators[0].Generate(append([]optionsFn{WithGetter(true), WithSetter(true)}, tc.options...)...)
+		if len(tc.errs) == 0 && err != nil {
+			t.Errorf("got error for %q: %s", tc.src, err.Error())
+		}
+		for _, e := range tc.errs {
+			if err == nil || !strings.Contains(err.Error(), e) {
+				t.Errorf("expected error %s for %q, got %v", e, tc.src, err)
+			}
+		}
+	}
+}
+
+func TestParseValidateTag(t *testing.T) {
+	type testCase struct {
+		value, kind, basic string
+		validations        []Validation
+		err                string
+	}
+	for _, tc := range []testCase{
+		{value: "", kind: "number", basic: "int"},
+		{value: "min=1,max=1.5", kind: "number", basic: "float64", validations: []Validation{{Rule: "min", Args: []string{"1"}, Kind: "number"}, {Rule: "max", Args: []string{"1.5"}, Kind: "number"}}},
+		{value: "min=-1,oneof=1e3 255", kind: "number", basic: "int16", validations: []Validation{{Rule: "min", Args: []string{"-1"}, Kind: "number"}, {Rule: "oneof", Args: []string{"1e3", "255"}, Kind: "number"}}},
+		{value: "nonempty,oneof=a b", kind: "string", validations: []Validation{{Rule: "nonempty", Kind: "string"}, {Rule: "oneof", Args: []string{`"a"`, `"b"`}, Kind: "string"}}},
+		{value: "max=3,regexp=^a,b{1,2}$", kind: "string", validations: []Validation{{Rule: "max", Args: []string{"3"}, Kind: "string"}, {Rule: "regexp", Args: []string{"^a,b{1,2}$"}, Kind: "string"}}},
+		{value: "nonempty", kind: "nil", validations: []Validation{{Rule: "nonempty", Kind: "nil"}}},
+		{value: "min=1", kind: "", err: "unsupported type"},
+		{value: "min=a", kind: "number", basic: "int", err: "invalid number"},
+		{value: "max=Inf", kind: "number", basic: "float64", err: "invalid number"},
+		{value: "min=1.5", kind: "number", basic: "int", err: `invalid min in validate tag "min=1.5": 1.5 can't be represented by int`},
+		{value: "min=-1", kind: "number", basic: "uint", err: `invalid min in validate tag "min=-1": -1 can't be represented by uint`},
+		{value: "max=256", kind: "number", basic: "uint8", err: "256 can't be represented by uint8"},
+		{value: "oneof=1 -2.5", kind: "number", basic: "int64", err: "-2.5 can't be represented by int64"},
+		{value: "max=1.5", kind: "length", err: "invalid length"},
+		{value: "min=1", kind: "nil", err: "unsupported option"},
+		{value: "oneof=1 a", kind: "number", basic: "int", err: "invalid number"},
+		{value: "oneof=", kind: "string", err: "empty oneof"},
+		{value: "regexp=(", kind: "string", err: "invalid regexp"},
+		{value: "regexp=a", kind: "number", basic: "int", err: "unsupported option"},
+		{value: "required", kind: "string", err: "unsupported option"},
+	} {
+		validations, err := parseValidateTag(tc.value, tc.kind, tc.basic)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error %s for %s, got %v", tc.err, tc.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.value, err.Error())
+		} else if !reflect.DeepEqual(validations, tc.validations) {
+			t.Errorf("got %+v for %s, expected %+v", validations, tc.value, tc.validations)
+		}
+	}
+}
+
+func TestNewGeneratorsHooks(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"s.go": `package test
+
+type S struct {
+	Name  string
+	count int
+	Items []int
+}
+
+func (s *S) validateName(v string) error { return nil }
+func (s S) beforeSetCount(old, new int) error { return nil }
+func (s *S) afterSetItems(old, new []int) {}
+func (s *S) afterSetName(old, new string) error { return nil }
+func (s *S) validateCount(v int64) error { return nil }
+func (s *S) beforeSetItems(v []int) error { return nil }
+func (s *S) afterSetMissing(old, new int) {}
+func (s *S) validatename(v string) error { return nil }
+`,
+	})
+
+	generators, err := NewGenerators([]string{"S"}, dir, false, buildConfig{})
+	if err != nil {
+		t.Fatalf("NewGenerators: %s", err.Error())
+	}
+	expected := map[string]Hook{
+		"validateName":   {Kind: "validate", Field: "Name", Err: true},
+		"beforeSetCount": {Kind: "beforeSet", Field: "count", Err: true},
+		"afterSetItems":  {Kind: "afterSet", Field: "Items"},
+		"afterSetName":   {Kind: "afterSet", Field: "Name", Err: true},
+	}
+	if hooks := generators[0].Hooks; !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("got hooks %+v, expected %+v", hooks, expected)
+	}
+}
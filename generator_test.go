@@ -4,69 +4,114 @@ import "testing"
 
 func TestFillTypeArguments(t *testing.T) {
 	type testCase struct {
-		param, arg string
-		input      string
-		output     string
+		args   map[string]string
+		input  string
+		output string
+		err    bool
 	}
 	for _, tc := range []testCase{
 		{
-			param:  "param",
-			arg:    "arg",
-			input:  "param param1 param2 _param3 param4_",
-			output: "arg param1 param2 _param3 param4_",
+			args:   map[string]string{"param": "arg"},
+			input:  "func(param, param1, _param3, param4_)",
+			output: "func(arg, param1, _param3, param4_)",
 		},
 		{
-			param:  "T",
-			arg:    "int",
+			args:   map[string]string{"T": "int"},
 			input:  "map[T]t",
 			output: "map[int]t",
 		},
 		{
-			param:  "T",
-			arg:    "int",
-			input:  "chan T <-chan T chan<- T chan a <-chan b chan<- c",
-			output: "chan int <-chan int chan<- int chan a <-chan b chan<- c",
+			args:   map[string]string{"T": "int"},
+			input:  "struct{a chan T; b <-chan T; c chan<- T; d chan a; e <-chan b; f chan<- c}",
+			output: "struct{a chan int; b <-chan int; c chan<- int; d chan a; e <-chan b; f chan<- c}",
 		},
 		{
-			param:  "T",
-			arg:    "int",
-			input:  "T is a type parameter, while T1 is not",
-			output: "int is a type parameter, while T1 is not",
+			args:   map[string]string{"T": "int"},
+			input:  "func(T1) T",
+			output: "func(T1) int",
 		},
 		{
-			param:  "T",
-			arg:    "int",
-			input:  "This is T",
-			output: "This is int",
-		},
-		{
-			param:  "T",
-			arg:    "int",
+			args:   map[string]string{"T": "int"},
 			input:  "(T)",
 			output: "(int)",
 		},
 		{
-			param:  "param",
-			arg:    "arg",
-			input:  "",
-			output: "",
+			args:   map[string]string{"param": "param"},
+			input:  "[]param",
+			output: "[]param",
 		},
 		{
-			param:  "param",
-			arg:    "param",
-			input:  "param should remain param",
-			output: "param should remain param",
+			// package qualifiers and selected names are not type parameters
+			args:   map[string]string{"T": "int", "time": "string"},
+			input:  "map[time.T]T",
+			output: "map[time.T]int",
 		},
 		{
-			param:  "T",
-			arg:    "int",
-			input:  "func[T]()T is a bad case, the expected result is func()int",
-			output: "func[int]()int is a bad case, the expected result is func()int",
+			// field and parameter names are not type parameters
+			args:   map[string]string{"T": "int"},
+			input:  "struct{ T T; F func(T T) T }",
+			output: "struct{T int; F func(T int) int}",
 		},
+		{
+			// type arguments are not substituted again
+			args:   map[string]string{"T1": "T2", "T2": "map[string]T1"},
+			input:  "map[T1]T2",
+			output: "map[T2]map[string]T1",
+		},
+		{
+			args:   map[string]string{"K": "Pair[int, string]", "V": "map[string]T2"},
+			input:  "Pair[K, []V]",
+			output: "Pair[Pair[int, string], []map[string]T2]",
+		},
+		{
+			// a function type can't have type parameters
+			args:  map[string]string{"T": "int"},
+			input: "func[T any]() T",
+			err:   true,
+		},
+	} {
+		got, err := fillTypeArguments(tc.input, tc.args)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error for %s, got %s", tc.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.input, err.Error())
+		} else if got != tc.output {
+			t.Errorf("got %q, expected %q", got, tc.output)
+		}
+	}
+}
+
+func TestQualifyType(t *testing.T) {
+	type testCase struct {
+		input  string
+		output string
+		err    bool
+	}
+	for _, tc := range []testCase{
+		{input: "int", output: "int"},
+		{input: "Book", output: "p.Book"},
+		{input: "map[string][]*Book", output: "map[string][]*p.Book"},
+		{input: "func(ctx context.Context, b Book) error", output: "func(ctx context.Context, b p.Book) error"},
+		{input: "struct{Book; Author any}", output: "struct{p.Book; Author any}"},
+		{input: "interface{Read(Book) int}", output: "interface{Read(p.Book) int}"},
+		{input: "book", err: true},
+		{input: "[]struct{b book}", err: true},
 	} {
-		got := fillTypeArguments(tc.input, tc.param, tc.arg)
-		if got != tc.output {
-			t.Errorf("got %s, expected %s", got, tc.output)
+		output, err := qualifyType(tc.input, "p")
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected error for %s, got %s", tc.input, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error for %s: %s", tc.input, err.Error())
+		} else if output != tc.output {
+			t.Errorf("got %s for %s, expected %s", output, tc.input, tc.output)
 		}
 	}
 }
@@ -1,14 +1,12 @@
 module github.com/yujiachen-y/goaccessor
 
-go 1.18
+go 1.22.0
 
 require (
 	github.com/robfig/cron/v3 v3.0.0
 	golang.org/x/exp v0.0.0-20230522175609-2e198f4a06a1
-	golang.org/x/tools v0.10.0
+	golang.org/x/mod v0.21.0
+	golang.org/x/tools v0.26.0
 )
 
-require (
-	golang.org/x/mod v0.11.0 // indirect
-	golang.org/x/sys v0.9.0 // indirect
-)
+require golang.org/x/sync v0.8.0 // indirect
@@ -67,6 +67,11 @@
 //	    return bestSellingBook.Author
 //	}
 //
+// Without --target, goaccessor runs the goaccessor //go:generate directives of the matching packages in one process,
+// loading the packages only once:
+//
+//	goaccessor ./...
+//
 // Options:
 //
 // Here are the available options for goaccessor:
@@ -79,6 +84,31 @@
 //	--field | -f: Apply the flag (getter, setter, accessor) to each field of the target (only applicable for struct type variables).
 //	--include | -i: Generate methods only for the specified fields (fields should be comma-separated).
 //	--exclude | -e: Exclude specified fields from method generation (fields should be comma-separated).
+//	--embed | -eb: Generate methods for the embedded fields, which are named after their types.
+//	--promote | -pr: Generate methods for the fields promoted from the embedded structs.
+//	--goos | -os: Load the package for the specified GOOS instead of the current one.
+//	--goarch | -arch: Load the package for the specified GOARCH instead of the current one.
+//	--tags | -tg: Load the package with the specified build tags (tags should be comma-separated).
+//	--conflict | -c: Decide what to do with a generated name which is declared already: skip (default), fail, or rename.
+//	--sync | -sy: Guard the accessors with the sync.Mutex or sync.RWMutex field of the target, or a sync.RWMutex declared for the variable.
+//	--lock | -l: Guard the accessors with the specified lock field or variable, which implies --sync.
+//	--chain | -ch: Make the setters return the receiver, so they can be chained (only applicable for struct types).
+//	--wither | -wi: Generate WithX methods returning a copy of the value receiver with the field set (only applicable for struct types).
+//	--constructor | -ct: Generate a constructor of the struct type taking the selected fields except the optional ones.
+//	--constructor-fields | -cf: Generate a constructor of the struct type taking a struct of the selected fields, which implies --constructor.
+//	--functional-options | -fo: Generate the functional options of the struct type, and a constructor applying them to the default value.
+//	--builder | -b: Generate a builder of the struct type, whose Build method reports the required fields which aren't set.
+//	--copy | -cp: Make the accessors clone the slice, map, and array-of-pointer fields, and generate Clone for the struct type.
+//	--validate | -va: Make the setters check the constraints in the validate tags of the fields and return errors, and generate Validate for the struct type.
+//	--nil-safe | -ns: Make the getters return the zero values if the receivers or the variables are nil pointers.
+//	--atomic | -at: Store the variable in a sync/atomic type, and generate SwapX and CompareAndSwapX with the setter (only applicable for pointer, integer, bool, and interface variables).
+//	--update | -up: Generate UpdateX applying a function to the variable with compare-and-swap retries, which implies --atomic.
+//	--update-context | -uc: Make UpdateX take a context, which stops the retries when it's done, implies --update.
+//	--update-retries | -ur: Make UpdateX give up after the specified attempts, implies --update.
+//	--interface | -if: Declare an interface of the specified name with the accessors of the target (only applicable for struct types).
+//	--getter-interface | -gif: Declare an interface of the specified name with the getters of the target.
+//	--setter-interface | -sif: Declare an interface of the specified name with the setters of the target.
+//	--interface-dir | -ifd: Declare the interfaces in the package in the specified directory instead of the target's package.
 //
 // Dependency Management:
 //
@@ -110,8 +140,11 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -131,157 +164,507 @@ func setupLogger() {
 	log.SetPrefix("goaccessor: ")
 }
 
-var (
-	flagTargets    []string
-	flagGetter     bool
-	flagSetter     bool
-	flagPureGetter bool
-	flagField      bool
-	flagPrefix     string
-	flagIncludes   []string
-	flagExcludes   []string
-	argDir         string
-)
+// errUsage is returned when goaccessor is invoked with invalid arguments.
+var errUsage = errors.New("invalid usage")
+
+// command is an invocation of goaccessor, from either the command line or a
+// go:generate directive.
+type command struct {
+	targets    []string
+	getter     bool
+	setter     bool
+	pureGetter bool
+	field      bool
+	prefix     string
+	includes   []string
+	excludes   []string
+	embed      bool
+	promote    bool
+	conflict   ConflictPolicy
+	// sync guards the accessors with the lock, which is found or declared
+	// for the target if it's empty.
+	sync bool
+	lock string
+	// chain makes the setters return the receiver.
+	chain bool
+	// wither generates WithX returning a modified copy of the receiver.
+	wither bool
+	// constructor generates a constructor of the struct type.
+	constructor bool
+	// constructorFields generates a constructor taking a struct of the fields.
+	constructorFields bool
+	// functionalOptions generates the functional options of the struct type.
+	functionalOptions bool
+	// builder generates a builder of the struct type.
+	builder bool
+	// copy makes the accessors clone the slices, maps, and arrays of pointers.
+	copy bool
+	// validate makes the setters check the validate tags of the fields.
+	validate bool
+	// nilSafe makes the getters return the zero values for nil pointers.
+	nilSafe bool
+	// atomic stores the variable in a sync/atomic type.
+	atomic bool
+	// update generates UpdateX in atomic mode, which takes a context if
+	// updateContext is set and gives up after updateRetries attempts if it's
+	// positive.
+	update        bool
+	updateContext bool
+	updateRetries int
+	// interfaces are declared with the accessors of the target, in the
+	// package in interfaceDir if it isn't empty.
+	interfaces   []Interface
+	interfaceDir string
+	build        buildConfig
+	// args are the arguments goaccessor is invoked with.
+	args []string
+	// paths are the directory or file of the targets, or the package patterns
+	// to look for go:generate directives when no target is specified.
+	paths []string
+}
 
-func parseFlags() {
-	t := flag.String("t", "", "")
-	target := flag.String("target", "", "")
-	g := flag.Bool("g", false, "")
-	getter := flag.Bool("getter", false, "")
-	s := flag.Bool("s", false, "")
-	setter := flag.Bool("setter", false, "")
-	a := flag.Bool("a", false, "")
-	accessor := flag.Bool("accessor", false, "")
-	pg := flag.Bool("pg", false, "")
-	pureGetter := flag.Bool("pure-getter", false, "")
-	f := flag.Bool("f", false, "")
-	field := flag.Bool("field", false, "")
-	p := flag.String("p", "", "")
-	prefix := flag.String("prefix", "", "")
-	i := flag.String("i", "", "")
-	include := flag.String("include", "", "")
-	e := flag.String("e", "", "")
-	exclude := flag.String("exclude", "", "")
-
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of goaccessor:\n")
-		fmt.Fprintf(os.Stderr, "\t--target -t string\n")
-		fmt.Fprintf(os.Stderr, "\t\tSpecify the target to be handled.\n")
-		fmt.Fprintf(os.Stderr, "\t--getter -g getter\n")
-		fmt.Fprintf(os.Stderr, "\t\tGenerate `getter` for the target.\n")
-		fmt.Fprintf(os.Stderr, "\t--setter -s getter\n")
-		fmt.Fprintf(os.Stderr, "\t\tGenerate `setter` for the target.\n")
-		fmt.Fprintf(os.Stderr, "\t--accessor -a getter\n")
-		fmt.Fprintf(os.Stderr, "\t\tGenerate `accessor` for the target.\n")
-		fmt.Fprintf(os.Stderr, "\t--pure-getter -pg getter\n")
-		fmt.Fprintf(os.Stderr, "\t\tGenerate `getter` without 'Get' prefix for the target.\n")
-		fmt.Fprintf(os.Stderr, "\t--field -f getter\n")
-		fmt.Fprintf(os.Stderr, "\t\tApply the command (`getter`, `setter`, `accessor`) to each field of the target (only works for struct type variables).\n")
-		fmt.Fprintf(os.Stderr, "\t--prefix -p string\n")
-		fmt.Fprintf(os.Stderr, "\t\tAdd a prefix to the generated methods/functions.\n")
-		fmt.Fprintf(os.Stderr, "\t--include -i string\n")
-		fmt.Fprintf(os.Stderr, "\t\tGenerate methods only for the specified fields (fields should be comma-separated).\n")
-		fmt.Fprintf(os.Stderr, "\t--exclude -e string\n")
-		fmt.Fprintf(os.Stderr, "\t\tExclude specified fields from method generation (fields should be comma-separated).\n")
-		fmt.Fprintf(os.Stderr, "For more information, see:\n")
-		fmt.Fprintf(os.Stderr, "\thttps://www.github.com/yujiachen-y/goaccessor\n")
-	}
-
-	flag.Parse()
+func parseCommand(args []string, output io.Writer) (*command, error) {
+	fs := flag.NewFlagSet("goaccessor", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	t := fs.String("t", "", "")
+	target := fs.String("target", "", "")
+	g := fs.Bool("g", false, "")
+	getter := fs.Bool("getter", false, "")
+	s := fs.Bool("s", false, "")
+	setter := fs.Bool("setter", false, "")
+	a := fs.Bool("a", false, "")
+	accessor := fs.Bool("accessor", false, "")
+	pg := fs.Bool("pg", false, "")
+	pureGetter := fs.Bool("pure-getter", false, "")
+	f := fs.Bool("f", false, "")
+	field := fs.Bool("field", false, "")
+	p := fs.String("p", "", "")
+	prefix := fs.String("prefix", "", "")
+	i := fs.String("i", "", "")
+	include := fs.String("include", "", "")
+	e := fs.String("e", "", "")
+	exclude := fs.String("exclude", "", "")
+	eb := fs.Bool("eb", false, "")
+	embed := fs.Bool("embed", false, "")
+	pr := fs.Bool("pr", false, "")
+	promote := fs.Bool("promote", false, "")
+	o := fs.String("os", "", "")
+	goos := fs.String("goos", "", "")
+	arch := fs.String("arch", "", "")
+	goarch := fs.String("goarch", "", "")
+	tg := fs.String("tg", "", "")
+	tags := fs.String("tags", "", "")
+	c := fs.String("c", "", "")
+	conflict := fs.String("conflict", "", "")
+	sy := fs.Bool("sy", false, "")
+	syncFlag := fs.Bool("sync", false, "")
+	l := fs.String("l", "", "")
+	lock := fs.String("lock", "", "")
+	ch := fs.Bool("ch", false, "")
+	chain := fs.Bool("chain", false, "")
+	wi := fs.Bool("wi", false, "")
+	wither := fs.Bool("wither", false, "")
+	ct := fs.Bool("ct", false, "")
+	constructor := fs.Bool("constructor", false, "")
+	cf := fs.Bool("cf", false, "")
+	constructorFields := fs.Bool("constructor-fields", false, "")
+	fo := fs.Bool("fo", false, "")
+	functionalOptions := fs.Bool("functional-options", false, "")
+	b := fs.Bool("b", false, "")
+	builder := fs.Bool("builder", false, "")
+	cp := fs.Bool("cp", false, "")
+	copyFlag := fs.Bool("copy", false, "")
+	va := fs.Bool("va", false, "")
+	validate := fs.Bool("validate", false, "")
+	ns := fs.Bool("ns", false, "")
+	nilSafe := fs.Bool("nil-safe", false, "")
+	at := fs.Bool("at", false, "")
+	atomic := fs.Bool("atomic", false, "")
+	up := fs.Bool("up", false, "")
+	update := fs.Bool("update", false, "")
+	uc := fs.Bool("uc", false, "")
+	updateContext := fs.Bool("update-context", false, "")
+	ur := fs.Int("ur", 0, "")
+	updateRetries := fs.Int("update-retries", 0, "")
+	ifc := fs.String("if", "", "")
+	iface := fs.String("interface", "", "")
+	gif := fs.String("gif", "", "")
+	getterIface := fs.String("getter-interface", "", "")
+	sif := fs.String("sif", "", "")
+	setterIface := fs.String("setter-interface", "", "")
+	ifd := fs.String("ifd", "", "")
+	ifaceDir := fs.String("interface-dir", "", "")
+
+	fs.Usage = func() {
+		usage(fs.Output())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+
+	cmd := &command{args: args, paths: fs.Args()}
 
 	if len(*t) != 0 {
-		flagTargets = strings.Split(*t, ",")
+		cmd.targets = strings.Split(*t, ",")
 	} else if len(*target) != 0 {
-		flagTargets = strings.Split(*target, ",")
+		cmd.targets = strings.Split(*target, ",")
 	}
-	if len(flagTargets) == 0 {
-		flag.Usage()
-		os.Exit(2)
+	// without targets, the targets come from the go:generate directives of
+	// the packages
+	if len(cmd.targets) == 0 {
+		if len(cmd.paths) == 0 {
+			fs.Usage()
+			return nil, errUsage
+		}
+		return cmd, nil
 	}
 
-	flagGetter = *g || *getter
-	flagSetter = *s || *setter
+	cmd.getter = *g || *getter
+	cmd.setter = *s || *setter
 	if *a || *accessor {
-		flagGetter = true
-		flagSetter = true
+		cmd.getter = true
+		cmd.setter = true
 	}
 	if *pg || *pureGetter {
-		flagGetter = true
-		flagPureGetter = true
+		cmd.getter = true
+		cmd.pureGetter = true
 	}
-	if !flagGetter && !flagSetter {
-		flag.Usage()
-		os.Exit(2)
+	// the withers, the builders, the functional options, and the constructors
+	// can be generated without other accessors
+	generated := *wi || *wither || *b || *builder || *fo || *functionalOptions || *ct || *constructor || *cf || *constructorFields
+	if !cmd.getter && !cmd.setter && !generated {
+		fs.Usage()
+		return nil, errUsage
 	}
 
-	flagField = *f || *field
+	cmd.field = *f || *field
 
 	if *p != "" {
-		flagPrefix = *p
+		cmd.prefix = *p
 	} else if *prefix != "" {
-		flagPrefix = *prefix
+		cmd.prefix = *prefix
 	}
 
 	if len(*i) != 0 {
-		flagIncludes = strings.Split(*i, ",")
+		cmd.includes = strings.Split(*i, ",")
 	} else if len(*include) != 0 {
-		flagIncludes = strings.Split(*include, ",")
+		cmd.includes = strings.Split(*include, ",")
 	}
 
 	if len(*e) != 0 {
-		flagExcludes = strings.Split(*e, ",")
+		cmd.excludes = strings.Split(*e, ",")
 	} else if len(*exclude) != 0 {
-		flagExcludes = strings.Split(*exclude, ",")
+		cmd.excludes = strings.Split(*exclude, ",")
 	}
 
-	args := flag.Args()
-	if len(args) == 0 {
-		args = []string{"."}
+	cmd.embed = *eb || *embed
+	cmd.promote = *pr || *promote
+
+	if *o != "" {
+		cmd.build.goos = *o
+	} else if *goos != "" {
+		cmd.build.goos = *goos
 	}
-	path := args[0]
-	pathInfo, err := os.Stat(path)
-	if err != nil {
-		log.Fatal(err)
+
+	if *arch != "" {
+		cmd.build.goarch = *arch
+	} else if *goarch != "" {
+		cmd.build.goarch = *goarch
 	}
-	if pathInfo.IsDir() {
-		argDir = path
+
+	if len(*tg) != 0 {
+		cmd.build.tags = strings.Split(*tg, ",")
+	} else if len(*tags) != 0 {
+		cmd.build.tags = strings.Split(*tags, ",")
+	}
+
+	cmd.conflict = ConflictSkip
+	if *c != "" {
+		cmd.conflict = ConflictPolicy(*c)
+	} else if *conflict != "" {
+		cmd.conflict = ConflictPolicy(*conflict)
+	}
+	switch cmd.conflict {
+	case ConflictSkip, ConflictFail, ConflictRename:
+	default:
+		fmt.Fprintf(fs.Output(), "unknown conflict policy %s\n", cmd.conflict)
+		return nil, errUsage
+	}
+
+	if *l != "" {
+		cmd.lock = *l
+	} else if *lock != "" {
+		cmd.lock = *lock
+	}
+	cmd.sync = *sy || *syncFlag || cmd.lock != ""
+
+	cmd.chain = *ch || *chain
+	cmd.wither = *wi || *wither
+	cmd.constructorFields = *cf || *constructorFields
+	cmd.constructor = *ct || *constructor || cmd.constructorFields
+	cmd.functionalOptions = *fo || *functionalOptions
+	cmd.builder = *b || *builder
+	cmd.copy = *cp || *copyFlag
+	cmd.validate = *va || *validate
+	cmd.nilSafe = *ns || *nilSafe
+
+	cmd.updateContext = *uc || *updateContext
+	if *ur != 0 {
+		cmd.updateRetries = *ur
 	} else {
-		argDir = filepath.Dir(path)
+		cmd.updateRetries = *updateRetries
+	}
+	if cmd.updateRetries < 0 {
+		fmt.Fprintf(fs.Output(), "invalid update retries %d\n", cmd.updateRetries)
+		return nil, errUsage
+	}
+	cmd.update = *up || *update || cmd.updateContext || cmd.updateRetries > 0
+	cmd.atomic = *at || *atomic || cmd.update
+	if cmd.atomic && cmd.sync {
+		fmt.Fprintf(fs.Output(), "--atomic and --update can't be used with --sync or --lock\n")
+		return nil, errUsage
+	}
+	if cmd.constructor && cmd.functionalOptions {
+		fmt.Fprintf(fs.Output(), "--constructor and --functional-options can't be used together, since both generate the NewX constructor\n")
+		return nil, errUsage
+	}
+
+	for _, i := range []struct {
+		short, long      *string
+		getters, setters bool
+	}{
+		{ifc, iface, true, true},
+		{gif, getterIface, true, false},
+		{sif, setterIface, false, true},
+	} {
+		name := *i.short
+		if name == "" {
+			name = *i.long
+		}
+		if name == "" {
+			continue
+		}
+		if !token.IsIdentifier(name) {
+			fmt.Fprintf(fs.Output(), "invalid interface name %s\n", name)
+			return nil, errUsage
+		}
+		cmd.interfaces = append(cmd.interfaces, Interface{Name: name, Getters: i.getters, Setters: i.setters})
 	}
+	if len(cmd.interfaces) > 0 && len(cmd.targets) > 1 {
+		fmt.Fprintf(fs.Output(), "only one target is allowed with interfaces, got %s\n", cmd.targets)
+		return nil, errUsage
+	}
+
+	if *ifd != "" {
+		cmd.interfaceDir = *ifd
+	} else if *ifaceDir != "" {
+		cmd.interfaceDir = *ifaceDir
+	}
+
+	if len(cmd.paths) > 1 {
+		fmt.Fprintf(fs.Output(), "only one directory or file is allowed with targets, got %s\n", cmd.paths)
+		return nil, errUsage
+	}
+	return cmd, nil
 }
 
-func main() {
-	setupLogger()
-	parseFlags()
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage of goaccessor:\n")
+	fmt.Fprintf(w, "\tgoaccessor [flags] --target T [directory | file]\n")
+	fmt.Fprintf(w, "\tgoaccessor [build flags] packages\n")
+	fmt.Fprintf(w, "Flags:\n")
+	fmt.Fprintf(w, "\t--target -t string\n")
+	fmt.Fprintf(w, "\t\tSpecify the target to be handled.\n")
+	fmt.Fprintf(w, "\t--getter -g getter\n")
+	fmt.Fprintf(w, "\t\tGenerate `getter` for the target.\n")
+	fmt.Fprintf(w, "\t--setter -s getter\n")
+	fmt.Fprintf(w, "\t\tGenerate `setter` for the target.\n")
+	fmt.Fprintf(w, "\t--accessor -a getter\n")
+	fmt.Fprintf(w, "\t\tGenerate `accessor` for the target.\n")
+	fmt.Fprintf(w, "\t--pure-getter -pg getter\n")
+	fmt.Fprintf(w, "\t\tGenerate `getter` without 'Get' prefix for the target.\n")
+	fmt.Fprintf(w, "\t--field -f getter\n")
+	fmt.Fprintf(w, "\t\tApply the command (`getter`, `setter`, `accessor`) to each field of the target (only works for struct type variables).\n")
+	fmt.Fprintf(w, "\t--prefix -p string\n")
+	fmt.Fprintf(w, "\t\tAdd a prefix to the generated methods/functions.\n")
+	fmt.Fprintf(w, "\t--include -i string\n")
+	fmt.Fprintf(w, "\t\tGenerate methods only for the specified fields (fields should be comma-separated).\n")
+	fmt.Fprintf(w, "\t--exclude -e string\n")
+	fmt.Fprintf(w, "\t\tExclude specified fields from method generation (fields should be comma-separated).\n")
+	fmt.Fprintf(w, "\t--embed -eb getter\n")
+	fmt.Fprintf(w, "\t\tGenerate methods for the embedded fields, which are named after their types.\n")
+	fmt.Fprintf(w, "\t--promote -pr getter\n")
+	fmt.Fprintf(w, "\t\tGenerate methods for the fields promoted from the embedded structs.\n")
+	fmt.Fprintf(w, "\t--goos -os string\n")
+	fmt.Fprintf(w, "\t\tLoad the package for the specified GOOS instead of the current one.\n")
+	fmt.Fprintf(w, "\t--goarch -arch string\n")
+	fmt.Fprintf(w, "\t\tLoad the package for the specified GOARCH instead of the current one.\n")
+	fmt.Fprintf(w, "\t--tags -tg string\n")
+	fmt.Fprintf(w, "\t\tLoad the package with the specified build tags (tags should be comma-separated).\n")
+	fmt.Fprintf(w, "\t--conflict -c string\n")
+	fmt.Fprintf(w, "\t\tDecide what to do with a generated name which is declared already: skip (default), fail, or rename.\n")
+	fmt.Fprintf(w, "\t--sync -sy getter\n")
+	fmt.Fprintf(w, "\t\tGuard the accessors with the sync.Mutex or sync.RWMutex field of the target, or a sync.RWMutex declared for the variable.\n")
+	fmt.Fprintf(w, "\t--lock -l string\n")
+	fmt.Fprintf(w, "\t\tGuard the accessors with the specified lock field or variable, which implies --sync.\n")
+	fmt.Fprintf(w, "\t--chain -ch getter\n")
+	fmt.Fprintf(w, "\t\tMake the setters return the receiver, so they can be chained (only works for struct types).\n")
+	fmt.Fprintf(w, "\t--wither -wi getter\n")
+	fmt.Fprintf(w, "\t\tGenerate WithX methods returning a copy of the value receiver with the field set (only works for struct types).\n")
+	fmt.Fprintf(w, "\t--constructor -ct getter\n")
+	fmt.Fprintf(w, "\t\tGenerate a constructor of the struct type taking the selected fields except the optional ones.\n")
+	fmt.Fprintf(w, "\t--constructor-fields -cf getter\n")
+	fmt.Fprintf(w, "\t\tGenerate a constructor of the struct type taking a struct of the selected fields, which implies --constructor.\n")
+	fmt.Fprintf(w, "\t--functional-options -fo getter\n")
+	fmt.Fprintf(w, "\t\tGenerate the functional options of the struct type, and a constructor applying them to the default value.\n")
+	fmt.Fprintf(w, "\t--builder -b getter\n")
+	fmt.Fprintf(w, "\t\tGenerate a builder of the struct type, whose Build method reports the required fields which aren't set.\n")
+	fmt.Fprintf(w, "\t--copy -cp getter\n")
+	fmt.Fprintf(w, "\t\tMake the accessors clone the slice, map, and array-of-pointer fields, and generate Clone for the struct type.\n")
+	fmt.Fprintf(w, "\t--validate -va getter\n")
+	fmt.Fprintf(w, "\t\tMake the setters check the constraints in the validate tags of the fields and return errors, and generate Validate for the struct type.\n")
+	fmt.Fprintf(w, "\t--nil-safe -ns getter\n")
+	fmt.Fprintf(w, "\t\tMake the getters return the zero values if the receivers or the variables are nil pointers.\n")
+	fmt.Fprintf(w, "\t--atomic -at getter\n")
+	fmt.Fprintf(w, "\t\tStore the variable in a sync/atomic type, and generate SwapX and CompareAndSwapX with the setter (only works for pointer, integer, bool, and interface variables).\n")
+	fmt.Fprintf(w, "\t--update -up getter\n")
+	fmt.Fprintf(w, "\t\tGenerate UpdateX applying a function to the variable with compare-and-swap retries, which implies --atomic.\n")
+	fmt.Fprintf(w, "\t--update-context -uc getter\n")
+	fmt.Fprintf(w, "\t\tMake UpdateX take a context, which stops the retries when it's done, implies --update.\n")
+	fmt.Fprintf(w, "\t--update-retries -ur int\n")
+	fmt.Fprintf(w, "\t\tMake UpdateX give up after the specified attempts, implies --update.\n")
+	fmt.Fprintf(w, "\t--interface -if string\n")
+	fmt.Fprintf(w, "\t\tDeclare an interface of the specified name with the accessors of the target (only works for struct types).\n")
+	fmt.Fprintf(w, "\t--getter-interface -gif string\n")
+	fmt.Fprintf(w, "\t\tDeclare an interface of the specified name with the getters of the target.\n")
+	fmt.Fprintf(w, "\t--setter-interface -sif string\n")
+	fmt.Fprintf(w, "\t\tDeclare an interface of the specified name with the setters of the target.\n")
+	fmt.Fprintf(w, "\t--interface-dir -ifd string\n")
+	fmt.Fprintf(w, "\t\tDeclare the interfaces in the package in the specified directory, relative to the target's directory.\n")
+	fmt.Fprintf(w, "Without --target, goaccessor runs the goaccessor go:generate directives of the packages in one process.\n")
+	fmt.Fprintf(w, "For more information, see:\n")
+	fmt.Fprintf(w, "\thttps://www.github.com/yujiachen-y/goaccessor\n")
+}
 
-	debug.Printf("Received arguments:\n")
-	debug.Printf("\t\tflagTargets %s\n", flagTargets)
-	debug.Printf("\t\tflagGetter %t\n", flagGetter)
-	debug.Printf("\t\tflagSetter %t\n", flagSetter)
-	debug.Printf("\t\tflagPureGetter %t\n", flagPureGetter)
-	debug.Printf("\t\tflagField %t\n", flagField)
-	debug.Printf("\t\tflagPrefix %s\n", flagPrefix)
-	debug.Printf("\t\tflagIncludes %s\n", flagIncludes)
-	debug.Printf("\t\tflagExcludes %s\n", flagExcludes)
-	debug.Printf("\t\targDir %s\n", argDir)
-
-	generators, err := NewGenerators(flagTargets, argDir, flagField)
+// dir returns the directory of the targets, paths are relative to base.
+func (c *command) dir(base string) (string, error) {
+	path := "."
+	if len(c.paths) > 0 {
+		path = c.paths[0]
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	pathInfo, err := os.Stat(path)
 	if err != nil {
-		log.Fatalf("Failed to create generators, error: %s", err.Error())
+		return "", err
+	}
+	if pathInfo.IsDir() {
+		return path, nil
 	}
+	return filepath.Dir(path), nil
+}
+
+func (c *command) debug() {
+	debug.Printf("Received arguments:\n")
+	debug.Printf("\t\ttargets %s\n", c.targets)
+	debug.Printf("\t\tgetter %t\n", c.getter)
+	debug.Printf("\t\tsetter %t\n", c.setter)
+	debug.Printf("\t\tpureGetter %t\n", c.pureGetter)
+	debug.Printf("\t\tfield %t\n", c.field)
+	debug.Printf("\t\tprefix %s\n", c.prefix)
+	debug.Printf("\t\tincludes %s\n", c.includes)
+	debug.Printf("\t\texcludes %s\n", c.excludes)
+	debug.Printf("\t\tembed %t\n", c.embed)
+	debug.Printf("\t\tpromote %t\n", c.promote)
+	debug.Printf("\t\tconflict %s\n", c.conflict)
+	debug.Printf("\t\tsync %t\n", c.sync)
+	debug.Printf("\t\tlock %s\n", c.lock)
+	debug.Printf("\t\tchain %t\n", c.chain)
+	debug.Printf("\t\twither %t\n", c.wither)
+	debug.Printf("\t\tconstructor %t\n", c.constructor)
+	debug.Printf("\t\tconstructorFields %t\n", c.constructorFields)
+	debug.Printf("\t\tfunctionalOptions %t\n", c.functionalOptions)
+	debug.Printf("\t\tbuilder %t\n", c.builder)
+	debug.Printf("\t\tcopy %t\n", c.copy)
+	debug.Printf("\t\tvalidate %t\n", c.validate)
+	debug.Printf("\t\tnilSafe %t\n", c.nilSafe)
+	debug.Printf("\t\tatomic %t\n", c.atomic)
+	debug.Printf("\t\tupdate %t\n", c.update)
+	debug.Printf("\t\tupdateContext %t\n", c.updateContext)
+	debug.Printf("\t\tupdateRetries %d\n", c.updateRetries)
+	debug.Printf("\t\tinterfaces %+v\n", c.interfaces)
+	debug.Printf("\t\tinterfaceDir %s\n", c.interfaceDir)
+	debug.Printf("\t\tbuild %+v\n", c.build)
+	debug.Printf("\t\tpaths %s\n", c.paths)
+}
 
+// generate writes the accessors of the generators.
+func (c *command) generate(generators []*Generator) error {
 	for _, generator := range generators {
 		log.Printf("generate %s ...\n", generator.Name)
 		err := generator.Generate(
-			WithGetter(flagGetter),
-			WithSetter(flagSetter),
-			WithPureGetter(flagPureGetter),
-			WithPrefix(flagPrefix),
-			WithIncludes(flagIncludes),
-			WithExcludes(flagExcludes),
+			WithGetter(c.getter),
+			WithSetter(c.setter),
+			WithPureGetter(c.pureGetter),
+			WithPrefix(c.prefix),
+			WithIncludes(c.includes),
+			WithExcludes(c.excludes),
+			WithEmbed(c.embed),
+			WithPromote(c.promote),
+			WithConflict(c.conflict),
+			WithSync(c.sync),
+			WithLock(c.lock),
+			WithChain(c.chain),
+			WithWither(c.wither),
+			WithConstructor(c.constructor),
+			WithConstructorFields(c.constructorFields),
+			WithFunctionalOptions(c.functionalOptions),
+			WithBuilder(c.builder),
+			WithCopy(c.copy),
+			WithValidate(c.validate),
+			WithNilSafe(c.nilSafe),
+			WithAtomic(c.atomic),
+			WithUpdate(c.update),
+			WithUpdateContext(c.updateContext),
+			WithUpdateRetries(c.updateRetries),
+			WithInterfaces(c.interfaces),
+			WithInterfaceDir(c.interfaceDir),
+			WithArgs(c.args),
 		)
 		if err != nil {
-			log.Fatalf("Failed to generate, error: %s", err.Error())
+			return fmt.Errorf("generate %s: %w", generator.Name, err)
 		}
 	}
+	return nil
+}
+
+func main() {
+	setupLogger()
+	cmd, err := parseCommand(os.Args[1:], os.Stderr)
+	if err != nil {
+		os.Exit(2)
+	}
+	cmd.debug()
+
+	if len(cmd.targets) == 0 {
+		if err := runDirectives(".", cmd.paths, cmd.build); err != nil {
+			log.Fatalf("Failed to run directives, error: %s", err.Error())
+		}
+		return
+	}
+
+	dir, err := cmd.dir(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	generators, err := NewGenerators(cmd.targets, dir, cmd.field, cmd.build)
+	if err != nil {
+		log.Fatalf("Failed to create generators, error: %s", err.Error())
+	}
+	if err := cmd.generate(generators); err != nil {
+		log.Fatalf("Failed to generate, error: %s", err.Error())
+	}
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandConflicts(t *testing.T) {
+	type testCase struct {
+		args []string
+		err  string
+	}
+	for _, tc := range []testCase{
+		{args: []string{"-t", "v", "-g", "-at", "-sy"}, err: "--atomic and --update can't be used with --sync or --lock"},
+		{args: []string{"-t", "S", "-fo", "-ct"}, err: "--constructor and --functional-options can't be used together"},
+		{args: []string{"-t", "S", "--functional-options", "--constructor-fields"}, err: "--constructor and --functional-options can't be used together"},
+		{args: []string{"-t", "S", "-fo", "-b"}},
+	} {
+		var output strings.Builder
+		_, err := parseCommand(tc.args, &output)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("got error for %q: %s", tc.args, err.Error())
+			}
+			continue
+		}
+		if !errors.Is(err, errUsage) || !strings.Contains(output.String(), tc.err) {
+			t.Errorf("expected %s for %q, got %v with output %q", tc.err, tc.args, err, output.String())
+		}
+	}
+}
+
+func TestGenerateStructOptions(t *testing.T) {
+	dir := writePackage(t, map[string]string{
+		"var.go": "package test\n\ntype S struct {\n\tTags []string\n}\n\nvar counter int\n\nvar s S\n",
+	})
+
+	type testCase struct {
+		args []string
+		err  string
+	}
+	for _, tc := range []testCase{
+		{args: []string{"-t", "counter", "-s", "-ch"}, err: "can't chain the setters of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-g", "-wi"}, err: "can't generate withers of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "s", "-f", "-g", "-wi"}, err: "can't generate withers of 's', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-g", "-wi", "-b", "-cp", "-va"}, err: "can't generate withers of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-g", "-b"}, err: "can't generate the builder of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-fo"}, err: "can't generate the functional options of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-ct"}, err: "can't generate the constructor of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-g", "-cp"}, err: "can't clone the fields of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "counter", "-s", "-va"}, err: "can't validate the fields of 'counter', which isn't a struct type"},
+		{args: []string{"-t", "s", "-f", "-b"}, err: "can't generate the builder of 's', which isn't a struct type"},
+		{args: []string{"-t", "s", "-f", "-g", "-s", "-cp", "-va"}},
+		{args: []string{"-t", "S", "-g", "-wi", "-ch"}},
+	} {
+		cmd, err := parseCommand(tc.args, io.Discard)
+		if err != nil {
+			t.Fatalf("parseCommand %q: %s", tc.args, err.Error())
+		}
+		generators, err := NewGenerators(cmd.targets, dir, cmd.field, cmd.build)
+		if err != nil {
+			t.Fatalf("NewGenerators %q: %s", tc.args, err.Error())
+		}
+		err = cmd.generate(generators)
+		if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.HasSuffix(err.Error(), tc.err)) {
+			t.Errorf("expected error %q for %q, got %v", tc.err, tc.args, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"go/types"
+	"strings"
+)
+
+// hookKinds are the prefixes of the names of the hooks, followed by the names
+// of the fields in upper case.
+var hookKinds = []string{"validate", "beforeSet", "afterSet"}
+
+// Hook is a method of the struct type called by the setter of a field, which
+// keeps the invariants of the field in hand-written code:
+//
+//	func (b *Book) validateTitle(v string) error
+//	func (b *Book) beforeSetTitle(old, new string) error
+//	func (b *Book) afterSetTitle(old, new string)
+//
+// validateTitle checks the new value before the lock is acquired, and
+// beforeSetTitle and afterSetTitle are called with the old and the new values
+// under the lock around the assignment. In copy mode, the new value is cloned
+// once and the hooks get the clone to store. The errors of the hooks are
+// returned by the setter. afterSetTitle may return an error as well, but the
+// new value is stored already then, and it's kept.
+type Hook struct {
+	// Kind is "validate", "beforeSet", or "afterSet".
+	Kind string
+	// Field is the name of the field.
+	Field string
+	// Err reports whether the hook returns an error.
+	Err bool
+}
+
+// hook returns the hook of the method of a struct type, whose signature is
+// sig, and whether it's a hook. The field is looked up in the receiver type,
+// so the types of a generic struct match the type parameters of the method.
+func hook(name string, sig *types.Signature) (Hook, bool) {
+	for _, kind := range hookKinds {
+		fieldName, ok := strings.CutPrefix(name, kind)
+		if !ok || fieldName == "" || fieldName != upper(fieldName) {
+			continue
+		}
+		field := hookField(sig.Recv().Type(), sig.Recv().Pkg(), fieldName)
+		if field == nil {
+			debug.Printf("%s isn't a hook of any field", name)
+			return Hook{}, false
+		}
+
+		params, results := sig.Params(), sig.Results()
+		h := Hook{Kind: kind, Field: field.Name(), Err: results.Len() == 1 && isError(results.At(0).Type())}
+		wantParams := 2
+		if kind == "validate" {
+			wantParams = 1
+		}
+		ok = params.Len() == wantParams && !sig.Variadic() && (h.Err || kind == "afterSet" && results.Len() == 0)
+		for i := 0; ok && i < params.Len(); i++ {
+			ok = types.Identical(params.At(i).Type(), field.Type())
+		}
+		if !ok {
+			debug.Printf("%s doesn't match the signature of the %s hook of field %s", name, kind, field.Name())
+			return Hook{}, false
+		}
+		return h, true
+	}
+	return Hook{}, false
+}
+
+// hookField returns the field of the struct type t named like name, which is
+// in upper case, or nil if there is no such field. The exported field is
+// preferred to the unexported one.
+func hookField(t types.Type, pkg *types.Package, name string) *types.Var {
+	for _, fieldName := range []string{name, lower(name)} {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, fieldName)
+		if field, ok := obj.(*types.Var); ok && field.IsField() {
+			return field
+		}
+	}
+	return nil
+}
+
+// isError reports whether t is the predeclared error type.
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// hooks returns the hooks of the field keyed by their kinds.
+func (g *Generator) hooks(field Field) map[string]Hook {
+	hooks := make(map[string]Hook)
+	for _, kind := range hookKinds {
+		if h, ok := g.Hooks[kind+upper(field.Name)]; ok && h.Field == field.Name {
+			hooks[kind] = h
+		}
+	}
+	return hooks
+}
+
+// hookCallCodeLines returns the statements calling the hook with the
+// arguments, which return its error if it returns one.
+func hookCallCodeLines(recv string, h Hook, args ...string) (cl codeLines) {
+	call := recv + "." + h.Kind + upper(h.Field) + "(" + strings.Join(args, ", ") + ")"
+	if !h.Err {
+		return cl.Append("        %s", call)
+	}
+	cl = cl.Append("        if err := %s; err != nil {", call)
+	cl = cl.Append("                return err")
+	cl = cl.Append("        }")
+	return
+}
+
+// oldValueName returns the name of the variable holding the old value of the
+// field for the afterSet hook, which doesn't shadow the receiver.
+func (g *Generator) oldValueName() string {
+	if g.getReceiverName() != "old" {
+		return "old"
+	}
+	return "prev"
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Interface is an interface declaring the accessors generated for a struct
+// type, together with an assertion that the type implements it.
+type Interface struct {
+	Name string
+	// Getters and Setters decide which accessors the interface declares.
+	Getters, Setters bool
+}
+
+// declares reports whether the interface declares the accessor.
+func (i Interface) declares(m accessorMethod) bool {
+	if m.setter {
+		return i.Setters
+	}
+	return i.Getters
+}
+
+func (i Interface) describe() string {
+	switch {
+	case i.Getters && i.Setters:
+		return "accessors"
+	case i.Getters:
+		return "getters"
+	default:
+		return "setters"
+	}
+}
+
+// accessorMethod is an accessor generated for a struct type.
+type accessorMethod struct {
+	name, typ string
+	// result is the result type of a chained setter, or "" for the others.
+	result string
+	setter bool
+}
+
+// signature returns the signature of the accessor, whose types are in typ and
+// result.
+func (m accessorMethod) signature(typ, result, newValueName string) string {
+	if m.setter && result != "" {
+		return fmt.Sprintf("%s(%s %s) %s", m.name, newValueName, typ, result)
+	}
+	if m.setter {
+		return fmt.Sprintf("%s(%s %s)", m.name, newValueName, typ)
+	}
+	return fmt.Sprintf("%s() %s", m.name, typ)
+}
+
+// getInterfaceCodeLines returns the interfaces declared in the package of the
+// target, whose names are resolved like the accessors.
+func (g *Generator) getInterfaceCodeLines() (cl codeLines, err error) {
+	if len(g.TypeParams) > 0 {
+		return nil, fmt.Errorf("can't declare interfaces for the generic type '%s'", g.Name)
+	}
+
+	for _, i := range g.opts.interfaces {
+		name, err := g.resolveTypeName(i.Name)
+		if err != nil {
+			return nil, err
+		}
+		cl = cl.Append("")
+		if name == "" {
+			cl = cl.Append("// %s already exists", i.Name)
+			continue
+		}
+		cl = cl.Append("// %s declares the %s of %s.", name, i.describe(), g.Name)
+		cl = cl.Append("type %s interface {", name)
+		for _, m := range g.accessorMethods {
+			if i.declares(m) {
+				cl = cl.Append("        %s", m.signature(m.typ, m.result, g.newValueName()))
+			}
+		}
+		cl = cl.Append("}")
+		cl = cl.Append("")
+		cl = cl.Append("var _ %s = (*%s)(nil)", name, g.Name)
+	}
+	return
+}
+
+// writeInterfaceFile writes the interfaces into the package in the interface
+// directory, which refers to the target and the types of its accessors by
+// importing the package of the target.
+func (g *Generator) writeInterfaceFile() error {
+	if len(g.TypeParams) > 0 {
+		return fmt.Errorf("can't declare interfaces for the generic type '%s'", g.Name)
+	}
+	if g.Test {
+		return fmt.Errorf("can't declare interfaces for '%s' in another package, which is declared in a test file", g.Name)
+	}
+	if !token.IsExported(g.Name) {
+		return fmt.Errorf("can't declare interfaces for '%s' in another package, which isn't exported", g.Name)
+	}
+
+	dir := g.opts.interfaceDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.Dir, dir)
+	}
+	pkgName, err := packageName(dir)
+	if err != nil {
+		return fmt.Errorf("packageName(%s): %w", dir, err)
+	}
+
+	names := make(map[string]bool, len(g.opts.interfaces))
+	for _, i := range g.opts.interfaces {
+		names[i.Name] = true
+	}
+	imports := append([]Import(nil), g.Imports...)
+	imports, alias := addImport(imports, g.Pkg, g.PkgPath, func(name string) bool {
+		return names[name]
+	})
+
+	var cl codeLines
+	for _, i := range g.opts.interfaces {
+		cl = cl.Append("")
+		cl = cl.Append("// %s declares the %s of %s.%s.", i.Name, i.describe(), g.Pkg, g.Name)
+		cl = cl.Append("type %s interface {", i.Name)
+		for _, m := range g.accessorMethods {
+			if !i.declares(m) {
+				continue
+			}
+			if !token.IsExported(m.name) {
+				return fmt.Errorf("%s of '%s' isn't exported, which can't be declared in another package", m.name, g.Name)
+			}
+			typ, err := qualifyType(m.typ, alias)
+			if err != nil {
+				return fmt.Errorf("can't declare %s of '%s' in another package: %w", m.name, g.Name, err)
+			}
+			result := m.result
+			if result != "" {
+				result, err = qualifyType(result, alias)
+				if err != nil {
+					return fmt.Errorf("can't declare %s of '%s' in another package: %w", m.name, g.Name, err)
+				}
+			}
+			cl = cl.Append("        %s", m.signature(typ, result, g.newValueName()))
+		}
+		cl = cl.Append("}")
+		cl = cl.Append("")
+		cl = cl.Append("var _ %s = (*%s.%s)(nil)", i.Name, alias, g.Name)
+	}
+
+	cl = append(g.getHeaderCodeLines(pkgName, imports), cl...)
+	return g.writeFile(g.InterfaceFilePath(), imports, cl)
+}
+
+// InterfaceFilePath is the path of the file of the interfaces declared in
+// another package, which is named after the package and the target.
+func (g *Generator) InterfaceFilePath() string {
+	dir := g.opts.interfaceDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.Dir, dir)
+	}
+	return filepath.Join(dir, strings.ToLower(g.Pkg+g.Name)+"_goaccessor.go")
+}
+
+// packageName returns the name of the package in dir, or the name of dir if
+// it has no Go file yet.
+func packageName(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("os.ReadDir: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", fmt.Errorf("parser.ParseFile: %w", err)
+		}
+		return file.Name.Name, nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	name := filepath.Base(absDir)
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("%s isn't a valid package name", name)
+	}
+	return name, nil
+}
+
+// qualifyType qualifies the types declared in the package of the target with
+// the name the package is imported as, so the type expression t can be used
+// in another package. The predeclared types are left untouched, and the
+// unexported types can't be referred to.
+func qualifyType(t, pkg string) (string, error) {
+	expr, err := parser.ParseExpr(t)
+	if err != nil {
+		return "", fmt.Errorf("parser.ParseExpr(%s): %w", t, err)
+	}
+
+	var unexported []string
+	expr = astutil.Apply(expr, func(c *astutil.Cursor) bool {
+		switch c.Parent().(type) {
+		case *ast.SelectorExpr:
+			// the types of other packages
+			return false
+		case *ast.Field:
+			if c.Name() == "Names" {
+				return false
+			}
+		}
+		ident, ok := c.Node().(*ast.Ident)
+		if !ok || types.Universe.Lookup(ident.Name) != nil {
+			return true
+		}
+		if !ident.IsExported() {
+			unexported = append(unexported, ident.Name)
+		}
+		c.Replace(&ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ident})
+		return false
+	}, nil).(ast.Expr)
+	if len(unexported) > 0 {
+		return "", fmt.Errorf("unexported types %s can't be referred to", unexported)
+	}
+	return types.ExprString(expr), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import "fmt"
+
+// lock is a sync.Mutex or sync.RWMutex guarding the accessors.
+type lock struct {
+	// expr refers to the lock in the accessors.
+	expr string
+	// kind is "Mutex" or "RWMutex".
+	kind string
+}
+
+// codeLines returns the statements acquiring the lock, which is released when
+// the accessor returns. The getters share an RWMutex with each other.
+func (l *lock) codeLines(write bool) (cl codeLines) {
+	if l == nil {
+		return nil
+	}
+	acquire, release := "Lock", "Unlock"
+	if !write && l.kind == "RWMutex" {
+		acquire, release = "RLock", "RUnlock"
+	}
+	cl = cl.Append("        %s.%s()", l.expr, acquire)
+	cl = cl.Append("        defer %s.%s()", l.expr, release)
+	return
+}
+
+// resolveLock resolves the lock guarding the accessors of the target in sync
+// mode, and returns the declaration of the lock if it's declared for the
+// target. The lock is the one specified by the options, or the only lock
+// field of the struct type, or a package level sync.RWMutex declared for the
+// variable.
+func (g *Generator) resolveLock() (cl codeLines, err error) {
+	g.lock = nil
+	if !g.opts.sync {
+		return nil, nil
+	}
+
+	switch g.GeneratorType {
+	case GeneratorTypeStructure, GeneratorTypeField:
+		recv := g.Name
+		if g.GeneratorType == GeneratorTypeStructure {
+			recv = g.getReceiverName()
+		}
+		if g.opts.lock != "" {
+			if l, ok := g.lockField(g.opts.lock, recv); ok {
+				g.lock = l
+				return nil, nil
+			}
+			break
+		}
+
+		var locks []Field
+		for _, field := range g.Fields {
+			if field.LockKind != "" && !field.Promoted {
+				locks = append(locks, field)
+			}
+		}
+		switch {
+		case len(locks) == 1:
+			g.lock = &lock{expr: recv + "." + locks[0].Name, kind: locks[0].LockKind}
+			return nil, nil
+		case len(locks) > 1:
+			return nil, fmt.Errorf("'%s' has more than one sync.Mutex or sync.RWMutex field, specify the lock by --lock", g.Name)
+		case g.GeneratorType == GeneratorTypeStructure:
+			return nil, fmt.Errorf("'%s' has no sync.Mutex or sync.RWMutex field to guard the accessors, specify the lock by --lock", g.Name)
+		}
+	}
+
+	if g.opts.lock != "" {
+		kind, ok := g.Locks[g.opts.lock]
+		if !ok {
+			return nil, fmt.Errorf("lock %s of '%s' isn't a sync.Mutex or sync.RWMutex", g.opts.lock, g.Name)
+		}
+		g.lock = &lock{expr: g.opts.lock, kind: kind}
+		return nil, nil
+	}
+
+	name := lower(g.Name) + "Mu"
+	lockName, err := g.resolve(name, "variable")
+	if err != nil {
+		return nil, err
+	}
+	if lockName == "" {
+		return nil, fmt.Errorf("%s already exists, specify the lock of '%s' by --lock", name, g.Name)
+	}
+	syncName := g.AddImport("sync", "sync", func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	})
+	cl = cl.Append("")
+	cl = cl.Append("// %s guards %s.", lockName, g.Name)
+	cl = cl.Append("var %s %s.RWMutex", lockName, syncName)
+	g.lock = &lock{expr: lockName, kind: "RWMutex"}
+	return cl, nil
+}
+
+// fieldLock returns the lock guarding the accessors of the field, or nil if
+// the field isn't guarded. The lock of the field configuration precedes the
+// one of the options.
+func (g *Generator) fieldLock(field Field, recv string) (*lock, error) {
+	if field.Config == nil || field.Config.Lock == "" {
+		return g.lock, nil
+	}
+	l, ok := g.lockField(field.Config.Lock, recv)
+	if !ok {
+		return nil, fmt.Errorf("lock %s of field %s isn't a sync.Mutex or sync.RWMutex field of '%s'", field.Config.Lock, field.Name, g.Name)
+	}
+	return l, nil
+}
+
+// lockField returns the lock field of the struct type, which is referred to
+// through recv.
+func (g *Generator) lockField(name, recv string) (*lock, bool) {
+	for _, field := range g.Fields {
+		if field.Name == name && field.LockKind != "" {
+			return &lock{expr: recv + "." + name, kind: field.LockKind}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,29 @@
+// atomictest contains the variables stored in the sync/atomic types in atomic
+// mode.
+package atomictest
+
+import "time"
+
+type Config struct {
+	Addr string
+}
+
+//go:generate go run ../../. -t config -a -at
+var config = &Config{Addr: "localhost"}
+
+//go:generate go run ../../. -t count -a -at
+var count int64 = 1
+
+type Level int8
+
+//go:generate go run ../../. -t level -a -at
+var level Level = 2
+
+//go:generate go run ../../. -t enabled -a -at
+var enabled = true
+
+//go:generate go run ../../. -t timeout -g -at
+var timeout = time.Second
+
+//go:generate go run ../../. -t handler -a -at
+var handler any
@@ -0,0 +1,61 @@
+package atomictest
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// initialCount is read during the initialization of the package variables,
+// which precedes the init functions.
+var initialCount = GetCount()
+
+func init() {
+	SetHandler("init")
+}
+
+func TestInitialValues(t *testing.T) {
+	if GetConfig().Addr != "localhost" || initialCount != 1 || GetLevel() != 2 || !GetEnabled() || GetTimeout() != time.Second || GetHandler() != "init" {
+		t.Errorf("got %v %d %d %t %s %v", GetConfig(), initialCount, GetLevel(), GetEnabled(), GetTimeout(), GetHandler())
+	}
+}
+
+func TestSwap(t *testing.T) {
+	c := &Config{Addr: "remote"}
+	if old := SwapConfig(c); old.Addr != "localhost" || GetConfig() != c {
+		t.Errorf("got %v and %v", old, GetConfig())
+	}
+	if CompareAndSwapLevel(1, 3) || !CompareAndSwapLevel(2, 3) || GetLevel() != 3 {
+		t.Errorf("got %d", GetLevel())
+	}
+	SetEnabled(false)
+	if SwapEnabled(true) {
+		t.Error("expected false")
+	}
+
+	// the stored types of an interface may differ
+	SetHandler(1)
+	if old := SwapHandler("test"); old != 1 || !CompareAndSwapHandler("test", nil) || GetHandler() != nil {
+		t.Errorf("got %v and %v", old, GetHandler())
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	SetCount(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if old := GetCount(); CompareAndSwapCount(old, old+1) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if GetCount() != 100 {
+		t.Errorf("expected 100, got %d", GetCount())
+	}
+}
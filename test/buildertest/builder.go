@@ -0,0 +1,40 @@
+// buildertest contains the builders of struct types.
+package buildertest
+
+import (
+	"sync"
+	"time"
+)
+
+//go:generate go run ../../. -t Request -b -g -e mu
+type Request struct {
+	Method string `accessor:"required"`
+	//goaccessor:required
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+	mu      sync.Mutex
+	retries int
+}
+
+//go:generate go run ../../. -t Generic -b -e Skipped
+type Generic[T any, U comparable] struct {
+	Value   T `accessor:"required"`
+	Keys    []U
+	Skipped bool
+}
+
+//go:generate go run ../../. -t options -b -cp -p with
+type options struct {
+	tags   []string
+	labels map[string]*Label `accessor:"clone"`
+}
+
+type Label struct {
+	Name string
+}
+
+func (l *Label) Clone() *Label {
+	c := *l
+	return &c
+}
@@ -0,0 +1,66 @@
+package buildertest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	r, err := NewRequestBuilder().Method("GET").URL("/").Timeout(time.Second).Retries(3).Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err.Error())
+	}
+	if r.Method != "GET" || r.URL != "/" || r.Headers != nil || r.Timeout != time.Second || r.retries != 3 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestBuildMissingRequiredFields(t *testing.T) {
+	_, err := NewRequestBuilder().URL("/").Build()
+	if err == nil || !strings.Contains(err.Error(), "missing required fields [Method]") {
+		t.Errorf("expected missing Method, got %v", err)
+	}
+	if _, err := NewGenericBuilder[int, string]().Build(); err == nil {
+		t.Error("expected missing Value")
+	}
+}
+
+func TestBuildGeneric(t *testing.T) {
+	g, err := NewGenericBuilder[*Request, string]().Value(&Request{Method: "PUT"}).Keys([]string{"a"}).Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err.Error())
+	}
+	if g.Value.Method != "PUT" || g.Keys[0] != "a" {
+		t.Errorf("got %+v", g)
+	}
+}
+
+func TestBuildClones(t *testing.T) {
+	tags := []string{"a"}
+	o, err := newOptionsBuilder().WithTags(tags).Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err.Error())
+	}
+	tags[0] = "b"
+	if o.tags[0] != "a" {
+		t.Errorf("the options are changed through the tags: %v", o.tags)
+	}
+}
+
+func TestBuildClonesEachValue(t *testing.T) {
+	b := newOptionsBuilder().WithTags([]string{"a"}).WithLabels(map[string]*Label{"x": {Name: "x"}})
+	o1, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err.Error())
+	}
+	o2, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err.Error())
+	}
+	o1.tags[0] = "b"
+	o1.labels["x"].Name = "y"
+	if o2.tags[0] != "a" || o2.labels["x"].Name != "x" {
+		t.Errorf("the values built by one builder share their fields: %v %v", o2.tags, o2.labels["x"])
+	}
+}
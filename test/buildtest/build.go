@@ -0,0 +1,14 @@
+// buildtest contains the targets declared under build constraints and in test
+// files. The generated files carry the same build constraints as the files
+// declaring the targets, and the targets of test files are generated into
+// test files.
+package buildtest
+
+// The platform of another GOOS is generated as well.
+//
+//go:generate go run ../../. -t platform -a -os windows
+
+//go:generate go run ../../. -t Plain -a
+type Plain struct {
+	name string
+}
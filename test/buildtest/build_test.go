@@ -0,0 +1,85 @@
+package buildtest
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+//go:generate go run ../../. -t fixture -a
+type fixture struct {
+	plain *Plain
+}
+
+func TestPlain(t *testing.T) {
+	p := &Plain{}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&p.name, p.SetName, "test1"),
+		utils.NewGetterVerifier(p.GetName, "test1"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestTagged(t *testing.T) {
+	tagged := &Tagged{}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&tagged.name, tagged.SetName, "test2"),
+		utils.NewGetterVerifier(tagged.GetName, "test2"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+	verifyConstraint(t, "taggedtagged_goaccessor.go", "//go:build !goaccessor_excluded")
+}
+
+func TestPlatform(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetPlatform, platform),
+		utils.NewSetterVerifier(&platform, SetPlatform, "test3"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+	// only the directive of the current platform is run
+	if runtime.GOOS == "linux" {
+		verifyConstraint(t, "platform_linuxplatform_goaccessor.go", "//go:build linux")
+	} else {
+		verifyConstraint(t, "platform_otherplatform_goaccessor.go", "//go:build !linux")
+	}
+}
+
+func TestFixture(t *testing.T) {
+	f := &fixture{}
+	p := &Plain{}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&f.plain, f.SetPlain, p),
+		utils.NewGetterVerifier(f.GetPlain, p),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+	if _, err := os.Stat("buildfixture_goaccessor_test.go"); err != nil {
+		t.Errorf("expected a generated test file: %s", err.Error())
+	}
+}
+
+// verifyConstraint verifies the generated file carries the build constraint.
+func verifyConstraint(t *testing.T, name, constraint string) {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("os.ReadFile %s: %s", name, err.Error())
+	}
+	if !strings.Contains(string(content), "\n"+constraint+"\n") {
+		t.Errorf("expected %s in %s, got:\n%s", constraint, name, content)
+	}
+}
@@ -0,0 +1,27 @@
+package buildtest_test
+
+import (
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/buildtest"
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+//go:generate go run ../../. -t External -a
+type External struct {
+	tagged buildtest.Tagged
+}
+
+func TestExternal(t *testing.T) {
+	e := &External{}
+	tagged := buildtest.Tagged{}
+	tagged.SetName("test4")
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&e.tagged, e.SetTagged, tagged),
+		utils.NewGetterVerifier(e.GetTagged, tagged),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package buildtest
+
+//go:generate go run ../../. -t platform -a
+var platform = "linux"
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package buildtest
+
+// platform is declared for every platform, only the one of the current
+// platform is seen.
+//
+//go:generate go run ../../. -t platform -a
+var platform = "other"
@@ -0,0 +1,8 @@
+//go:build !goaccessor_excluded
+
+package buildtest
+
+//go:generate go run ../../. -t Tagged -a
+type Tagged struct {
+	name string
+}
@@ -0,0 +1,50 @@
+// chaintest contains the chained setters and the withers of struct types.
+package chaintest
+
+//go:generate go run ../../. -t Book -a -ch -wi
+type Book struct {
+	Title  string
+	Author string
+	// the ID of a book isn't changed
+	ID   int `accessor:"readonly"`
+	Tags []string
+}
+
+//go:generate go run ../../. -t Point -g -wi -e Label
+type Point struct {
+	X, Y  float64
+	Label string
+}
+
+//go:generate go run ../../. -t Money -wi -p amount -cp
+type Money struct {
+	Value    int64
+	Currency string
+	Splits   []int64
+}
+
+//go:generate go run ../../. -t Entry -s -ch
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+//go:generate go run ../../. -t Page -s -ch -sif PageSetter
+type Page struct {
+	Number int
+}
+
+type Base struct {
+	City string
+}
+
+type Place struct {
+	Zip int
+}
+
+//go:generate go run ../../. -t Location -wi -pr
+type Location struct {
+	*Base
+	Place
+	Name string
+}
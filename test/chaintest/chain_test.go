@@ -0,0 +1,59 @@
+package chaintest
+
+import "testing"
+
+func TestChainedSetters(t *testing.T) {
+	b := &Book{}
+	if got := b.SetTitle("Dune").SetAuthor("Herbert").SetTags([]string{"sf"}); got != b {
+		t.Errorf("expected the receiver, got %p", got)
+	}
+	if b.Title != "Dune" || b.Author != "Herbert" || b.Tags[0] != "sf" {
+		t.Errorf("got %+v", b)
+	}
+
+	e := (&Entry[string, int]{}).SetKey("a").SetValue(1)
+	if e.Key != "a" || e.Value != 1 {
+		t.Errorf("got %+v", e)
+	}
+
+	var s PageSetter = &Page{}
+	if s.SetNumber(1).Number != 1 {
+		t.Error("expected 1")
+	}
+}
+
+func TestWithers(t *testing.T) {
+	b := Book{Title: "Dune", ID: 1}
+	c := b.WithTitle("Emma").WithAuthor("Austen")
+	if b.Title != "Dune" || b.Author != "" || c.Title != "Emma" || c.Author != "Austen" || c.ID != 1 {
+		t.Errorf("got %+v from %+v", c, b)
+	}
+
+	p := Point{Label: "origin"}.WithX(1).WithY(2)
+	if p != (Point{X: 1, Y: 2, Label: "origin"}) {
+		t.Errorf("got %+v", p)
+	}
+
+	splits := []int64{1, 2}
+	m := Money{}.WithAmountValue(3).WithAmountCurrency("EUR").WithAmountSplits(splits)
+	splits[0] = 0
+	if m.Value != 3 || m.Currency != "EUR" || m.Splits[0] != 1 {
+		t.Errorf("got %+v", m)
+	}
+}
+
+func TestPromotedWithers(t *testing.T) {
+	l := Location{Base: &Base{City: "Paris"}, Name: "home"}
+	c := l.WithZip(75001).WithName("work")
+	if l.Zip != 0 || l.Name != "home" || c.Zip != 75001 || c.Name != "work" {
+		t.Errorf("got %+v from %+v", c, l)
+	}
+	// City is promoted through *Base, which is shared by the copies, so it
+	// has no wither
+	if _, ok := any(l).(interface{ WithCity(string) Location }); ok {
+		t.Error("expected no WithCity")
+	}
+	if l.City != "Paris" {
+		t.Errorf("expected the original city, got %s", l.City)
+	}
+}
@@ -0,0 +1,46 @@
+// conflicttest contains the targets whose generated names conflict with the
+// declarations of the package, or with the names generated for other targets.
+package conflicttest
+
+import "strings"
+
+func GetConfig() string {
+	return "declared"
+}
+
+//go:generate go run ../../. -t config -a
+var config = "config"
+
+// width gets GetWidth2 along with Width, see width.go.
+var width = 1
+
+func GetLimit() int {
+	return -1
+}
+
+//go:generate go run ../../. -t limit -a -c rename
+var limit = 1
+
+type Book struct {
+	title  string
+	Author string
+}
+
+func (b *Book) SetTitle(title string) {
+	b.title = strings.ToUpper(title)
+}
+
+// Book gets GetTitle, GetAuthor and SetAuthor, Novel gets the same methods on
+// Book, which are renamed.
+//
+//go:generate go run ../../. -t Book -a
+//go:generate go run ../../. -t Novel -g -c rename
+type Novel = Book
+
+// Essay gets Title and a renamed Author, which is the name of its field.
+//
+//go:generate go run ../../. -t Essay -pg -c rename
+type Essay struct {
+	title  string
+	Author string
+}
@@ -0,0 +1,79 @@
+package conflicttest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+func TestConfig(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetConfig, "declared"),
+		utils.NewSetterVerifier(&config, SetConfig, "test1"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	content, err := os.ReadFile("conflictconfig_goaccessor.go")
+	if err != nil {
+		t.Fatalf("os.ReadFile: %s", err.Error())
+	}
+	if !strings.Contains(string(content), "// GetConfig already exists") {
+		t.Errorf("expected GetConfig to be skipped, got:\n%s", content)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetLimit, -1),
+		utils.NewSetterVerifier(&limit, SetLimit, 2),
+		utils.NewGetterVerifier(GetLimit2, 2),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestBook(t *testing.T) {
+	b := &Book{}
+	b.SetTitle("test2")
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(b.GetTitle, "TEST2"),
+		utils.NewGetterVerifier(b.GetTitle2, "TEST2"),
+		utils.NewSetterVerifier(&b.Author, b.SetAuthor, "test3"),
+		utils.NewGetterVerifier(b.GetAuthor, "test3"),
+		utils.NewGetterVerifier(b.GetAuthor2, "test3"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestEssay(t *testing.T) {
+	e := &Essay{title: "test4", Author: "test5"}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(e.Title, "test4"),
+		utils.NewGetterVerifier(e.Author2, "test5"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetWidth, 2),
+		utils.NewGetterVerifier(GetWidth2, 1),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
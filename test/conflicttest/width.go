@@ -0,0 +1,8 @@
+package conflicttest
+
+// Width and width are generated in one run, which shares the generated names,
+// so the getter of width, sorted after Width, is renamed. They are declared in
+// different files, so their generated files don't collide.
+//
+//go:generate go run ../../. -t width,Width -g -c rename
+var Width = 2
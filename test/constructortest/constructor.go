@@ -0,0 +1,42 @@
+// constructortest contains the constructors of struct types.
+package constructortest
+
+import (
+	"sync"
+	"time"
+)
+
+//go:generate go run ../../. -t Book -ct
+type Book struct {
+	Title  string
+	Author string
+	// the pages are counted later
+	Pages int `accessor:"optional"`
+}
+
+//go:generate go run ../../. -t Request -cf -cp -e Retries
+type Request struct {
+	mu      sync.Mutex
+	Method  string
+	Type    string
+	Headers map[string][]string
+	//goaccessor:optional
+	//goaccessor:default 30 * time.Second
+	Timeout time.Duration
+	Retries int `accessor:"default=3"`
+}
+
+//go:generate go run ../../. -t Pair -ct
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+//go:generate go run ../../. -t Point -ct
+type Point struct {
+	X, Y int
+}
+
+func NewPoint(x, y int) *Point {
+	return &Point{X: x, Y: y}
+}
@@ -0,0 +1,34 @@
+package constructortest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstructor(t *testing.T) {
+	b := NewBook("Dune", "Herbert")
+	if b.Title != "Dune" || b.Author != "Herbert" || b.Pages != 0 {
+		t.Errorf("got %+v", b)
+	}
+
+	p := NewPair("a", 1)
+	if p.Key != "a" || p.Value != 1 {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestConstructorDefaults(t *testing.T) {
+	headers := map[string][]string{"Accept": {"*/*"}}
+	r := NewRequest("GET", "json", headers)
+	delete(headers, "Accept")
+	if r.Method != "GET" || r.Type != "json" || len(r.Headers) != 1 || r.Timeout != 30*time.Second || r.Retries != 3 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestConstructorFromFields(t *testing.T) {
+	r := NewRequestFromFields(RequestFields{Method: "POST", Timeout: time.Second})
+	if r.Method != "POST" || r.Type != "" || r.Timeout != time.Second || r.Retries != 0 {
+		t.Errorf("got %+v", r)
+	}
+}
@@ -30,31 +30,64 @@ const (
 	numberOfDays // this constant is not exported
 )
 
-/*
-Maybe we should support the (constant expression)[https://go.dev/ref/spec#Constant_expressions]
- but not now.
-
-const a = 2 + 3.0          // a == 5.0   (untyped floating-point constant)
-const b = 15 / 4           // b == 3     (untyped integer constant)
-const c = 15 / 4.0         // c == 3.75  (untyped floating-point constant)
-const Θ float64 = 3/2      // Θ == 1.0   (type float64, 3/2 is integer division)
-const Π float64 = 3/2.     // Π == 1.5   (type float64, 3/2. is float division)
-const d = 1 << 3.0         // d == 8     (untyped integer constant)
-const e = 1.0 << 3         // e == 8     (untyped integer constant)
-const f = int32(1) << 33   // illegal    (constant 8589934592 overflows int32)
-const g = float64(2) >> 1  // illegal    (float64(2) is a typed floating-point constant)
-const h = "foo" > "bar"    // h == true  (untyped boolean constant)
-const j = true             // j == true  (untyped boolean constant)
-const k = 'w' + 1          // k == 'x'   (untyped rune constant)
-const l = "hi"             // l == "hi"  (untyped string constant)
-const m = string(k)        // m == "x"   (type string)
-const Σ = 1 - 0.707i       //            (untyped complex constant)
-const Δ = Σ + 2.0e-4       //            (untyped complex constant)
-const Φ = iota*1i - 1/1i   //            (untyped complex constant)
-
-const ic = complex(0, c)   // ic == 3.75i  (untyped complex constant)
-const iΘ = complex(0, Θ)   // iΘ == 1i     (type complex128)
-
-const Huge = 1 << 100         // Huge == 1267650600228229401496703205376  (untyped integer constant)
-const Four int8 = Huge >> 98  // Four == 4                                (type int8)
-*/
+// The following cases come from the (constant expression)[https://go.dev/ref/spec#Constant_expressions]
+// section of the spec, the illegal ones are left out.
+
+//go:generate go run ../../. -t sum,quo,fquo,Θ,Π,shl,fshl,gt,yes,next,hi,str,Σ,Δ,Φ,ic,iΘ -g
+const (
+	sum          = 2 + 3.0        // sum == 5.0    (untyped floating-point constant)
+	quo          = 15 / 4         // quo == 3      (untyped integer constant)
+	fquo         = 15 / 4.0       // fquo == 3.75  (untyped floating-point constant)
+	Θ    float64 = 3 / 2          // Θ == 1.0      (type float64, 3/2 is integer division)
+	Π    float64 = 3 / 2.         // Π == 1.5      (type float64, 3/2. is float division)
+	shl          = 1 << 3.0       // shl == 8      (untyped integer constant)
+	fshl         = 1.0 << 3       // fshl == 8     (untyped integer constant)
+	gt           = "foo" > "bar"  // gt == true    (untyped boolean constant)
+	yes          = true           // yes == true   (untyped boolean constant)
+	next         = 'w' + 1        // next == 'x'   (untyped rune constant)
+	hi           = "hi"           // hi == "hi"    (untyped string constant)
+	str          = string(next)   // str == "x"    (type string)
+	Σ            = 1 - 0.707i     //               (untyped complex constant)
+	Δ            = Σ + 2.0e-4     //               (untyped complex constant)
+	Φ            = iota*1i - 1/1i //               (untyped complex constant)
+
+	ic = complex(0, fquo) // ic == 3.75i  (untyped complex constant)
+	iΘ = complex(0, Θ)    // iΘ == 1i     (type complex128)
+)
+
+// Huge can't be accessed by a getter, since it overflows its default type int.
+const Huge = 1 << 100 // Huge == 1267650600228229401496703205376  (untyped integer constant)
+
+//go:generate go run ../../. -t Four -g
+const Four int8 = Huge >> 98 // Four == 4  (type int8)
+
+//go:generate go run ../../. -t FlagUp,FlagBroadcast,FlagLoopback -g
+const (
+	FlagUp = 1 << iota
+	FlagBroadcast
+	FlagLoopback
+)
+
+//go:generate go run ../../. -t KiB,MiB,GiB -g
+const (
+	KiB = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+)
+
+type ByteSize float64
+
+//go:generate go run ../../. -t KB,MB -g
+const (
+	_           = iota // ignore first value by assigning to blank identifier
+	KB ByteSize = 1 << (10 * iota)
+	MB
+)
+
+type Weekday int
+
+//go:generate go run ../../. -t Mon,Tue -g
+const (
+	Mon Weekday = iota
+	Tue
+)
@@ -33,3 +33,50 @@ func TestConstGet(t *testing.T) {
 		}
 	}
 }
+
+func TestConstExpressionGet(t *testing.T) {
+	// the explicit type arguments make sure the getters return the default types.
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier[float64](GetSum, 5.0),
+		utils.NewGetterVerifier[int](GetQuo, 3),
+		utils.NewGetterVerifier[float64](GetFquo, 3.75),
+		utils.NewGetterVerifier[float64](GetΘ, 1.0),
+		utils.NewGetterVerifier[float64](GetΠ, 1.5),
+		utils.NewGetterVerifier[int](GetShl, 8),
+		utils.NewGetterVerifier[int](GetFshl, 8),
+		utils.NewGetterVerifier[bool](GetGt, true),
+		utils.NewGetterVerifier[bool](GetYes, true),
+		utils.NewGetterVerifier[rune](GetNext, 'x'),
+		utils.NewGetterVerifier[string](GetHi, "hi"),
+		utils.NewGetterVerifier[string](GetStr, "x"),
+		utils.NewGetterVerifier[complex128](GetΣ, Σ),
+		utils.NewGetterVerifier[complex128](GetΔ, Δ),
+		utils.NewGetterVerifier[complex128](GetΦ, Φ),
+		utils.NewGetterVerifier[complex128](GetIc, 3.75i),
+		utils.NewGetterVerifier[complex128](GetIΘ, 1i),
+		utils.NewGetterVerifier[int8](GetFour, 4),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got err: %s", err.Error())
+		}
+	}
+}
+
+func TestIotaGet(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier[int](GetFlagUp, 1),
+		utils.NewGetterVerifier[int](GetFlagBroadcast, 2),
+		utils.NewGetterVerifier[int](GetFlagLoopback, 4),
+		utils.NewGetterVerifier[int](GetKiB, 1<<10),
+		utils.NewGetterVerifier[int](GetMiB, 1<<20),
+		utils.NewGetterVerifier[int](GetGiB, 1<<30),
+		utils.NewGetterVerifier[ByteSize](GetKB, 1<<10),
+		utils.NewGetterVerifier[ByteSize](GetMB, 1<<20),
+		utils.NewGetterVerifier[Weekday](GetMon, 0),
+		utils.NewGetterVerifier[Weekday](GetTue, 1),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got err: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// copytest contains the accessors cloning the slices, maps, and arrays of
+// pointers in copy mode, and the Clone methods of the struct types.
+package copytest
+
+import "sync"
+
+//go:generate go run ../../. -t Order -a -cp
+type Order struct {
+	ID     int
+	Tags   []string
+	Attrs  map[string]string
+	Slots  [2]*int
+	Coords [2]int
+	// the lines are cloned by the Clone method of Line
+	Lines    []*Line `accessor:"clone"`
+	Customer *Customer
+	//goaccessor:clone
+	Address Address
+	Notes   map[string]*Line `accessor:"clone"`
+}
+
+//go:generate go run ../../. -t Line -a -cp
+type Line struct {
+	SKU      string
+	Quantity int
+	Options  []string
+}
+
+//go:generate go run ../../. -t Address -a -cp
+type Address struct {
+	Lines []string
+}
+
+type Customer struct {
+	Name string
+}
+
+//go:generate go run ../../. -t Inventory -a -cp -sy
+type Inventory struct {
+	mu    sync.RWMutex
+	Items map[string]int
+}
+
+//go:generate go run ../../. -t Bag -a -cp
+type Bag[T any] struct {
+	Items []T
+}
+
+//go:generate go run ../../. -t defaultOrder -f -a -cp
+var defaultOrder = &Order{Tags: []string{"default"}}
@@ -0,0 +1,96 @@
+package copytest
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGetterClones(t *testing.T) {
+	one := 1
+	o := &Order{
+		Tags:    []string{"a"},
+		Attrs:   map[string]string{"k": "v"},
+		Slots:   [2]*int{&one},
+		Lines:   []*Line{{SKU: "x", Options: []string{"gift"}}},
+		Address: Address{Lines: []string{"street"}},
+		Notes:   map[string]*Line{"n": {SKU: "y"}},
+	}
+
+	o.GetTags()[0] = "b"
+	o.GetAttrs()["k"] = "w"
+	*o.GetSlots()[0] = 2
+	o.GetLines()[0].Options[0] = "wrap"
+	o.GetAddress().Lines[0] = "avenue"
+	o.GetNotes()["n"].SKU = "z"
+	if o.Tags[0] != "a" || o.Attrs["k"] != "v" || *o.Slots[0] != 1 || o.Lines[0].Options[0] != "gift" || o.Address.Lines[0] != "street" || o.Notes["n"].SKU != "y" {
+		t.Errorf("the order is changed through its getters: %+v", o)
+	}
+	if o.GetLines()[0].SKU != "x" || o.GetSlots()[1] != nil {
+		t.Errorf("got %v and %v", o.GetLines()[0], o.GetSlots())
+	}
+}
+
+func TestSetterClones(t *testing.T) {
+	o := &Order{}
+	tags := []string{"a"}
+	lines := []*Line{{SKU: "x"}}
+	o.SetTags(tags)
+	o.SetLines(lines)
+	tags[0], lines[0].SKU = "b", "y"
+	if o.Tags[0] != "a" || o.Lines[0].SKU != "x" {
+		t.Errorf("the order is changed through the values set: %v %v", o.Tags, o.Lines[0])
+	}
+
+	o.SetTags(nil)
+	o.SetNotes(nil)
+	if o.Tags != nil || o.Notes != nil {
+		t.Errorf("expected nil, got %v and %v", o.Tags, o.Notes)
+	}
+}
+
+func TestClone(t *testing.T) {
+	if (*Order)(nil).Clone() != nil {
+		t.Error("expected nil")
+	}
+
+	customer := &Customer{Name: "Alice"}
+	o := &Order{ID: 1, Tags: []string{"a"}, Lines: []*Line{{SKU: "x", Options: []string{"gift"}}, nil}, Customer: customer}
+	c := o.Clone()
+	c.Tags[0] = "b"
+	c.Lines[0].Options[0] = "wrap"
+	if c.ID != 1 || c.Customer != customer || c.Lines[1] != nil || o.Tags[0] != "a" || o.Lines[0].Options[0] != "gift" {
+		t.Errorf("got %+v from %+v", c, o)
+	}
+
+	b := &Bag[int]{Items: []int{1}}
+	b.Clone().Items[0] = 2
+	if b.Items[0] != 1 {
+		t.Errorf("the bag is changed through its clone: %v", b.Items)
+	}
+}
+
+func TestConcurrentClone(t *testing.T) {
+	inventory := &Inventory{Items: map[string]int{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			items := inventory.GetItems()
+			items["apple"]++
+			inventory.SetItems(items)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = inventory.Clone().Items["apple"]
+		}()
+	}
+	wg.Wait()
+}
+
+func TestVariable(t *testing.T) {
+	GetTags()[0] = "changed"
+	if defaultOrder.Tags[0] != "default" {
+		t.Errorf("the variable is changed through its getter: %v", defaultOrder.Tags)
+	}
+}
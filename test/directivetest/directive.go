@@ -0,0 +1,17 @@
+// directivetest contains the fields configured by the goaccessor directives in
+// their comments.
+package directivetest
+
+//go:generate go run ../../. -t User -a -e secret
+type User struct {
+	//goaccessor:getter name=ID
+	id       int
+	name     string //goaccessor:setter name=Rename
+	password string //goaccessor:skip
+	//goaccessor:getter unexported
+	//goaccessor:setter
+	token string
+	// secret is excluded by the option, though it has a directive.
+	secret string //goaccessor:getter
+	age    int
+}
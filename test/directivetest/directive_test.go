@@ -0,0 +1,30 @@
+package directivetest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+func TestUser(t *testing.T) {
+	u := &User{id: 1, name: "test2", token: "test3"}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(u.GetID, 1),
+		utils.NewSetterVerifier(&u.name, u.SetRename, "test4"),
+		utils.NewGetterVerifier(u.getToken, "test3"),
+		utils.NewSetterVerifier(&u.token, u.SetToken, "test5"),
+		utils.NewSetterVerifier(&u.age, u.SetAge, 6),
+		utils.NewGetterVerifier(u.GetAge, 6),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	for _, name := range []string{"SetID", "GetName", "GetRename", "GetPassword", "SetPassword", "GetToken", "GetSecret"} {
+		if _, ok := reflect.TypeOf(u).MethodByName(name); ok {
+			t.Errorf("unexpected method %s", name)
+		}
+	}
+}
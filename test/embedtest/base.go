@@ -0,0 +1,12 @@
+package embedtest
+
+type Base struct {
+	Name    string
+	Version string
+	inner
+}
+
+type inner struct {
+	ID   int
+	deep bool
+}
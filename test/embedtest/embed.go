@@ -0,0 +1,25 @@
+// embedtest covers embedded structs declared in other files and packages.
+package embedtest
+
+import "github.com/yujiachen-y/goaccessor/test/embedtest/meta"
+
+// Order gets accessors for Amount, Name, ID (from meta.Metadata, which
+// shadows inner.ID), and deep. Version is ambiguous between Base and
+// meta.Metadata, and labels is not exported by meta, so they are left out.
+//
+//go:generate go run ../../. -t Order -a -pr
+type Order struct {
+	Base
+	*meta.Metadata
+	Amount int
+}
+
+//go:generate go run ../../. -t Item -a -eb
+type Item struct {
+	Base
+	*meta.Metadata
+	Count int
+}
+
+//go:generate go run ../../. -t defaultOrder -f -g -pr -p default
+var defaultOrder = Order{Base: Base{Name: "order"}, Metadata: &meta.Metadata{ID: "1"}}
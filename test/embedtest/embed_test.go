@@ -0,0 +1,68 @@
+package embedtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/embedtest/meta"
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+func TestPromote(t *testing.T) {
+	o := Order{Metadata: &meta.Metadata{}}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&o.Amount, o.SetAmount, 2),
+		utils.NewGetterVerifier(o.GetAmount, 2),
+		utils.NewSetterVerifier(&o.Name, o.SetName, "3"),
+		utils.NewGetterVerifier(o.GetName, "3"),
+		utils.NewSetterVerifier(&o.ID, o.SetID, "4"),
+		utils.NewGetterVerifier(o.GetID, "4"),
+		utils.NewSetterVerifier(&o.deep, o.SetDeep, true),
+		utils.NewGetterVerifier(o.GetDeep, true),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	for _, name := range []string{"GetVersion", "SetVersion", "GetLabels", "SetLabels", "GetBase", "GetMetadata", "GetInner"} {
+		if _, ok := reflect.TypeOf(&o).MethodByName(name); ok {
+			t.Errorf("unexpected method %s", name)
+		}
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	i := Item{}
+	base := Base{Name: "5"}
+	metadata := &meta.Metadata{ID: "6"}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&i.Base, i.SetBase, base),
+		utils.NewGetterVerifier(i.GetBase, base),
+		utils.NewSetterVerifier(&i.Metadata, i.SetMetadata, metadata),
+		utils.NewGetterVerifier(i.GetMetadata, metadata),
+		utils.NewSetterVerifier(&i.Count, i.SetCount, 7),
+		utils.NewGetterVerifier(i.GetCount, 7),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	if _, ok := reflect.TypeOf(&i).MethodByName("GetName"); ok {
+		t.Errorf("unexpected method GetName")
+	}
+}
+
+func TestPromoteField(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetDefaultName, "order"),
+		utils.NewGetterVerifier(GetDefaultID, "1"),
+		utils.NewGetterVerifier(GetDefaultAmount, 0),
+		utils.NewGetterVerifier(GetDefaultDeep, false),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
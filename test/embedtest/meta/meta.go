@@ -0,0 +1,7 @@
+package meta
+
+type Metadata struct {
+	ID      string
+	Version int
+	labels  map[string]string
+}
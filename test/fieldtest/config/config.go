@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type Config struct {
+	Name    string
+	Tags    []string
+	Timeout time.Duration
+	secret  string
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+	Next  *Pair[K, V]
+	count int
+}
@@ -1,13 +1,17 @@
-// fieldtest contains only cases we support. Other cases like the following
-// which refers to other package is beyond the scope of this package.
-//
-//	var a struct_type_from_other_package
-//	var b = struct_type_from_other_package{}
+// fieldtest contains only cases we support. The struct types may come from
+// other packages, in which case only the exported fields are accessed.
 //
 // Please note, field accessors are only supported for struct types. And we
-// don't support nil or concurrent access checking.
+// don't support nil checking, the concurrent access is guarded in sync mode,
+// see synctest.
 package fieldtest
 
+import (
+	"time"
+
+	"github.com/yujiachen-y/goaccessor/test/fieldtest/config"
+)
+
 type Normal struct {
 	a, B int
 	c    *int64
@@ -67,3 +71,33 @@ type Pure struct {
 
 //go:generate go run ../../. -t pure -a -pg -f
 var pure Pure
+
+//go:generate go run ../../. -t remote1 -a -f -p remote1
+var remote1 config.Config
+
+//go:generate go run ../../. -t remote2 -a -f -p remote2
+var remote2 = &config.Config{}
+
+//go:generate go run ../../. -t remote3 -a -f -p remote3
+var remote3 config.Pair[string, time.Duration]
+
+type Pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// T2 shares its name with a type parameter of Nested.
+type T2 struct {
+	n int
+}
+
+type Nested[T1 any, T2 any] struct {
+	first  T1
+	second T2
+	pairs  []Pair[string, T1]
+	lookup map[string]func(T1) T2
+	shadow struct{ T1 T2 }
+}
+
+//go:generate go run ../../. -t nested -a -f -p nested
+var nested Nested[Pair[int, string], map[string]T2]
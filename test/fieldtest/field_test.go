@@ -2,7 +2,9 @@ package fieldtest
 
 import (
 	"testing"
+	"time"
 
+	"github.com/yujiachen-y/goaccessor/test/fieldtest/config"
 	"github.com/yujiachen-y/goaccessor/test/utils"
 )
 
@@ -235,3 +237,58 @@ func TestPure(t *testing.T) {
 		}
 	}
 }
+
+func TestRemote(t *testing.T) {
+	testTags := []string{"test2", "test3"}
+	next := &config.Pair[string, time.Duration]{}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&remote1.Name, SetRemote1Name, "test1"),
+		utils.NewGetterVerifier(GetRemote1Name, "test1"),
+		utils.NewSliceSetterVerifier(&remote1.Tags, SetRemote1Tags, testTags),
+		utils.NewSliceGetterVerifier(GetRemote1Tags, testTags),
+		utils.NewSetterVerifier(&remote1.Timeout, SetRemote1Timeout, time.Second),
+		utils.NewGetterVerifier(GetRemote1Timeout, time.Second),
+		utils.NewSetterVerifier(&remote2.Name, SetRemote2Name, "test4"),
+		utils.NewGetterVerifier(GetRemote2Name, "test4"),
+		utils.NewSetterVerifier(&remote3.Key, SetRemote3Key, "test5"),
+		utils.NewGetterVerifier(GetRemote3Key, "test5"),
+		utils.NewSetterVerifier(&remote3.Value, SetRemote3Value, time.Minute),
+		utils.NewGetterVerifier(GetRemote3Value, time.Minute),
+		utils.NewSetterVerifier(&remote3.Next, SetRemote3Next, next),
+		utils.NewGetterVerifier(GetRemote3Next, next),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestNested(t *testing.T) {
+	testFirst := Pair[int, string]{key: 1, value: "test2"}
+	testSecond := map[string]T2{"test3": {n: 4}}
+	testPairs := []Pair[string, Pair[int, string]]{{key: "test5", value: testFirst}}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&nested.first, SetNestedFirst, testFirst),
+		utils.NewGetterVerifier(GetNestedFirst, testFirst),
+		utils.NewMapSetterVerifier(&nested.second, SetNestedSecond, testSecond),
+		utils.NewMapGetterVerifier(GetNestedSecond, testSecond),
+		utils.NewSliceSetterVerifier(&nested.pairs, SetNestedPairs, testPairs),
+		utils.NewSliceGetterVerifier(GetNestedPairs, testPairs),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	SetNestedLookup(map[string]func(Pair[int, string]) map[string]T2{
+		"test6": func(p Pair[int, string]) map[string]T2 { return map[string]T2{p.value: {n: p.key}} },
+	})
+	if got := GetNestedLookup()["test6"](testFirst)["test2"].n; got != 1 {
+		t.Errorf("expected 1 got %d", got)
+	}
+
+	SetNestedShadow(struct{ T1 map[string]T2 }{T1: testSecond})
+	if got := GetNestedShadow().T1["test3"].n; got != 4 {
+		t.Errorf("expected 4 got %d", got)
+	}
+}
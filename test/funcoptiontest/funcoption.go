@@ -0,0 +1,33 @@
+// funcoptiontest contains the functional options of struct types.
+package funcoptiontest
+
+import "time"
+
+//go:generate go run ../../. -t Server -fo
+type Server struct {
+	Addr    string        `accessor:"default=localhost:8080"`
+	Timeout time.Duration `accessor:"default=30 * time.Second"`
+	//goaccessor:default []string{"GET", "POST"}
+	Methods []string
+	Debug   bool
+}
+
+//go:generate go run ../../. -t Client -fo -p client -e Name
+type Client struct {
+	Name    string
+	Retries int
+}
+
+func defaultClient() Client {
+	return Client{Name: "default", Retries: 3}
+}
+
+//go:generate go run ../../. -t Cache -fo -cp
+type Cache[K comparable, V any] struct {
+	Size  int
+	Items map[K]V
+}
+
+func defaultCache[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{Size: 16}
+}
@@ -0,0 +1,43 @@
+package funcoptiontest
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDefaultValues(t *testing.T) {
+	s := NewServer()
+	if s.Addr != "localhost:8080" || s.Timeout != 30*time.Second || !slices.Equal(s.Methods, []string{"GET", "POST"}) || s.Debug {
+		t.Errorf("got %+v", s)
+	}
+
+	c := NewClient()
+	if c.Name != "default" || c.Retries != 3 {
+		t.Errorf("got %+v", c)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	s := NewServer(WithAddr(":80"), WithDebug(true))
+	if s.Addr != ":80" || s.Timeout != 30*time.Second || !s.Debug {
+		t.Errorf("got %+v", s)
+	}
+
+	c := NewClient(WithClientRetries(5))
+	if c.Name != "default" || c.Retries != 5 {
+		t.Errorf("got %+v", c)
+	}
+}
+
+func TestGenericOptions(t *testing.T) {
+	items := map[string]int{"a": 1}
+	c := NewCache(WithSize[string, int](32), WithItems(items))
+	items["a"] = 2
+	if c.Size != 32 || c.Items["a"] != 1 {
+		t.Errorf("got %+v", c)
+	}
+	if c := NewCache[string, int](); c.Size != 16 {
+		t.Errorf("expected the default size, got %d", c.Size)
+	}
+}
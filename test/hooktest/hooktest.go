@@ -0,0 +1,100 @@
+// hooktest contains the setters calling the hooks of the fields declared by
+// hand, which keep the invariants of the fields.
+package hooktest
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ../../. -t Book -g -s -sy -e mu,history,updates -va -cp -if BookAccessor
+type Book struct {
+	mu sync.Mutex
+
+	Title  string `validate:"nonempty"`
+	Tags   []string
+	Pages  int
+	author string
+
+	history []string
+	updates int
+}
+
+func (b *Book) validateTitle(v string) error {
+	if strings.TrimSpace(v) != v {
+		return errors.New("Title has surrounding spaces")
+	}
+	return nil
+}
+
+func (b *Book) beforeSetTitle(old, new string) error {
+	if old == new {
+		return errors.New("Title isn't changed")
+	}
+	return nil
+}
+
+func (b *Book) afterSetTitle(old, new string) {
+	b.history = append(b.history, old)
+}
+
+func (b *Book) validateTags(v []string) error {
+	if slices.Contains(v, "") {
+		return errors.New("Tags has an empty tag")
+	}
+	return nil
+}
+
+// beforeSetTags lowers the new tags in place, which doesn't change the tags of
+// the caller since they're cloned.
+func (b *Book) beforeSetTags(old, new []string) error {
+	for i, tag := range new {
+		new[i] = strings.ToLower(tag)
+	}
+	return nil
+}
+
+func (b *Book) afterSetTags(old, new []string) {
+	b.updates++
+}
+
+func (b *Book) validatePages(v int) error {
+	if v%2 != 0 {
+		return errors.New("Pages must be even")
+	}
+	return nil
+}
+
+func (b *Book) beforeSetAuthor(old, new string) error {
+	if old != "" {
+		return errors.New("author is set already")
+	}
+	return nil
+}
+
+// beforeSetPages doesn't match the signature of the hooks, so it's left alone.
+func (b *Book) beforeSetPages(v int) {}
+
+//go:generate go run ../../. -t Box -s
+type Box[T comparable] struct {
+	Value T
+	count int
+}
+
+func (b Box[T]) validateValue(v T) error {
+	var zero T
+	if v == zero {
+		return errors.New("Value is zero")
+	}
+	return nil
+}
+
+func (b *Box[T]) afterSetValue(old, new T) error {
+	b.count++
+	if old == new {
+		return errors.New("Value isn't changed")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package hooktest
+
+import (
+	"slices"
+	"testing"
+)
+
+var _ BookAccessor = &Book{}
+
+func TestHooks(t *testing.T) {
+	b := &Book{}
+	for _, tt := range []struct {
+		name string
+		set  func() error
+		err  string
+	}{
+		{"validate tag", func() error { return b.SetTitle("") }, "Title must not be empty"},
+		{"validate hook", func() error { return b.SetTitle(" Go ") }, "Title has surrounding spaces"},
+		{"first title", func() error { return b.SetTitle("Go") }, ""},
+		{"same title", func() error { return b.SetTitle("Go") }, "Title isn't changed"},
+		{"second title", func() error { return b.SetTitle("Rust") }, ""},
+		{"odd pages", func() error { return b.SetPages(3) }, "Pages must be even"},
+		{"even pages", func() error { return b.SetPages(4) }, ""},
+		{"first author", func() error { return b.SetAuthor("Rob") }, ""},
+		{"second author", func() error { return b.SetAuthor("Ken") }, "author is set already"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.set()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+	if b.GetTitle() != "Rust" || !slices.Equal(b.history, []string{"", "Go"}) {
+		t.Errorf("unexpected title %s and history %v", b.GetTitle(), b.history)
+	}
+	if b.GetPages() != 4 || b.GetAuthor() != "Rob" {
+		t.Errorf("unexpected pages %d and author %s", b.GetPages(), b.GetAuthor())
+	}
+
+	b.Pages = 5
+	if err := b.Validate(); err == nil || err.Error() != "Pages must be even" {
+		t.Errorf("expected the error of the validate hook, got %v", err)
+	}
+
+	tags := []string{"A", "b"}
+	if err := b.SetTags(tags); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !slices.Equal(b.GetTags(), []string{"a", "b"}) || !slices.Equal(tags, []string{"A", "b"}) {
+		t.Errorf("expected the clone to be lowered and stored, got %v and %v", b.GetTags(), tags)
+	}
+	if err := b.SetTags([]string{"c", ""}); err == nil || err.Error() != "Tags has an empty tag" {
+		t.Errorf("expected the error of the validate hook, got %v", err)
+	}
+	if err := b.SetTags(nil); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if b.updates != 2 {
+		t.Errorf("expected 2 updates, got %d", b.updates)
+	}
+}
+
+func TestGenericHooks(t *testing.T) {
+	b := &Box[int]{}
+	if err := b.SetValue(0); err == nil || b.count != 0 {
+		t.Errorf("unexpected error %v, count %d", err, b.count)
+	}
+	if err := b.SetValue(1); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := b.SetValue(1); err == nil || b.Value != 1 || b.count != 2 {
+		t.Errorf("unexpected error %v, value %d, count %d", err, b.Value, b.count)
+	}
+}
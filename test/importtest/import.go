@@ -1,6 +1,9 @@
 package importtest
 
 import (
+	"context"
+	"time"
+
 	"github.com/robfig/cron/v3"
 	"github.com/yujiachen-y/goaccessor/test/importtest/p1"
 	p3 "github.com/yujiachen-y/goaccessor/test/importtest/p2"
@@ -27,3 +30,23 @@ type Generic[T any] struct{ T T }
 
 //go:generate go run ../../. -t generic -f -a
 var generic = Generic[p1.Option1]{T: "T"}
+
+// The imports are collected from every part of the composite types.
+//
+//go:generate go run ../../. -t Composite -a
+type Composite struct {
+	Options  map[string]*p1.Option2
+	Timeouts []time.Duration
+	Handler  func(context.Context) error
+	Events   chan<- p3.Struct
+	Nested   map[p1.Option1][]*p3.Struct
+	Generic  Generic[map[string]time.Duration]
+	Anon     struct{ Option p1.Option1 }
+	Iface    interface{ Next(time.Time) time.Time }
+}
+
+//go:generate go run ../../. -t handlers -a
+var handlers map[string]func(context.Context, *p3.Struct) (time.Duration, error)
+
+//go:generate go run ../../. -t pairs -f -a -p pairs
+var pairs Generic[[]*p1.Option2]
@@ -0,0 +1,22 @@
+package importtest
+
+import (
+	_ "embed"
+	htemplate "html/template"
+	ttemplate "text/template"
+
+	. "github.com/yujiachen-y/goaccessor/test/importtest/p1"
+	c "github.com/yujiachen-y/goaccessor/test/importtest/v2"
+)
+
+// conflict shadows the name of package github.com/yujiachen-y/goaccessor/test/importtest/v2.
+var conflict = 1
+
+//go:generate go run ../../. -t Resolve -a
+type Resolve struct {
+	Dot      Option1
+	DotPtr   *Option2
+	Conflict c.Value
+	Text     *ttemplate.Template
+	HTML     *htemplate.Template
+}
@@ -1,7 +1,11 @@
 package importtest
 
 import (
+	"context"
+	"errors"
+	htemplate "html/template"
 	"testing"
+	ttemplate "text/template"
 	"time"
 
 	"github.com/yujiachen-y/goaccessor/test/importtest/p1"
@@ -92,3 +96,83 @@ func TestGeneric(t *testing.T) {
 		}
 	}
 }
+
+func TestResolve(t *testing.T) {
+	r := &Resolve{}
+	option2 := new(p1.Option2)
+	text := ttemplate.New("text")
+	html := htemplate.New("html")
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&r.Dot, r.SetDot, "10"),
+		utils.NewGetterVerifier(r.GetDot, "10"),
+		utils.NewSetterVerifier(&r.DotPtr, r.SetDotPtr, option2),
+		utils.NewGetterVerifier(r.GetDotPtr, option2),
+		utils.NewSetterVerifier(&r.Conflict, r.SetConflict, 11),
+		utils.NewGetterVerifier(r.GetConflict, 11),
+		utils.NewSetterVerifier(&r.Text, r.SetText, text),
+		utils.NewGetterVerifier(r.GetText, text),
+		utils.NewSetterVerifier(&r.HTML, r.SetHTML, html),
+		utils.NewGetterVerifier(r.GetHTML, html),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+	_ = conflict
+}
+
+func TestComposite(t *testing.T) {
+	c := &Composite{}
+	options := map[string]*p1.Option2{"option": new(p1.Option2)}
+	timeouts := []time.Duration{time.Second}
+	handlerErr := errors.New("handler")
+	events := make(chan p2.Struct, 1)
+	generic := Generic[map[string]time.Duration]{T: map[string]time.Duration{"generic": time.Minute}}
+	anon := struct{ Option p1.Option1 }{Option: "12"}
+	c.SetOptions(options)
+	c.SetTimeouts(timeouts)
+	c.SetHandler(func(context.Context) error { return handlerErr })
+	c.SetEvents(events)
+	c.SetNested(map[p1.Option1][]*p2.Struct{"nested": {{}}})
+	c.SetGeneric(generic)
+	c.SetAnon(anon)
+	c.SetIface(&testSchedule{})
+	for _, verifier := range []utils.Verifier{
+		utils.NewMapGetterVerifier(c.GetOptions, options),
+		utils.NewSliceGetterVerifier(c.GetTimeouts, timeouts),
+		utils.NewGetterVerifier(func() error { return c.GetHandler()(context.Background()) }, handlerErr),
+		utils.NewGetterVerifier(func() int { return len(c.GetNested()["nested"]) }, 1),
+		utils.NewMapGetterVerifier(func() map[string]time.Duration { return c.GetGeneric().T }, generic.T),
+		utils.NewGetterVerifier(c.GetAnon, anon),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+	c.GetEvents() <- p2.Struct{Option1: "13"}
+	if got := <-events; got.Option1 != "13" {
+		t.Errorf("expected 13 got %s", got.Option1)
+	}
+	if c.GetIface() == nil {
+		t.Errorf("expected non-nil iface")
+	}
+}
+
+func TestCompositeVar(t *testing.T) {
+	SetHandlers(map[string]func(context.Context, *p2.Struct) (time.Duration, error){
+		"handler": func(context.Context, *p2.Struct) (time.Duration, error) { return time.Hour, nil },
+	})
+	if d, _ := GetHandlers()["handler"](context.Background(), nil); d != time.Hour {
+		t.Errorf("expected %v got %v", time.Hour, d)
+	}
+
+	option2s := []*p1.Option2{new(p1.Option2)}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSliceSetterVerifier(&pairs.T, SetPairsT, option2s),
+		utils.NewSliceGetterVerifier(GetPairsT, option2s),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
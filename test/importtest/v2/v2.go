@@ -0,0 +1,5 @@
+// Package conflict has a name different from its directory, and the name is
+// declared in package importtest as well.
+package conflict
+
+type Value int
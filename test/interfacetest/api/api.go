@@ -0,0 +1,2 @@
+// Package api contains the interfaces declared for the types of interfacetest.
+package api
@@ -0,0 +1,19 @@
+// interfacetest contains the interfaces declared with the accessors of the
+// struct types, in the same package or another one.
+package interfacetest
+
+import "time"
+
+//go:generate go run ../../. -t Book -a -e secret -gif BookReader -sif BookWriter
+type Book struct {
+	title  string
+	author *Author
+	secret string
+}
+
+//go:generate go run ../../. -t Author -g -if AuthorReader -ifd api
+type Author struct {
+	name  string
+	born  time.Time
+	books []*Book
+}
@@ -0,0 +1,23 @@
+package interfacetest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBook(t *testing.T) {
+	var b BookWriter = &Book{}
+	b.SetTitle("test1")
+	b.SetAuthor(&Author{name: "test2"})
+
+	var r BookReader = b.(*Book)
+	if r.GetTitle() != "test1" || r.GetAuthor().name != "test2" {
+		t.Errorf("got %s and %v", r.GetTitle(), r.GetAuthor())
+	}
+
+	for _, iface := range []reflect.Type{reflect.TypeOf((*BookReader)(nil)).Elem(), reflect.TypeOf((*BookWriter)(nil)).Elem()} {
+		if iface.NumMethod() != 2 {
+			t.Errorf("expected 2 methods of %s, got %d", iface, iface.NumMethod())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// nilsafetest contains the getters returning the zero values for nil pointers
+// in nil-safe mode.
+package nilsafetest
+
+//go:generate go run ../../. -t Order -g -ns
+type Order struct {
+	ID       int
+	Customer *Customer
+	Items    []string
+}
+
+//go:generate go run ../../. -t Customer -g -ns
+type Customer struct {
+	Name    string
+	Address *Address
+	Tags    map[string]bool
+}
+
+//go:generate go run ../../. -t Address -g -ns
+type Address struct {
+	City  string
+	Point Point
+}
+
+type Point struct {
+	X, Y float64
+}
+
+//go:generate go run ../../. -t Pair -g -ns
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+//go:generate go run ../../. -t currentOrder -f -g -ns
+var currentOrder *Order
+
+//go:generate go run ../../. -t homeAddress -f -g -ns
+var homeAddress Address
+
+//go:generate go run ../../. -t Shipment -g -pr -ns
+type Shipment struct {
+	*Parcel
+	ID int
+}
+
+type Parcel struct {
+	*Label
+	Weight float64
+}
+
+type Label struct {
+	Text string
+}
+
+//go:generate go run ../../. -t lastShipment -f -g -pr -ns -p Last
+var lastShipment Shipment
@@ -0,0 +1,72 @@
+package nilsafetest
+
+import "testing"
+
+func TestNilReceivers(t *testing.T) {
+	var o *Order
+	if o.GetID() != 0 || o.GetCustomer() != nil || o.GetItems() != nil {
+		t.Errorf("got %d %v %v", o.GetID(), o.GetCustomer(), o.GetItems())
+	}
+	if city := o.GetCustomer().GetAddress().GetCity(); city != "" {
+		t.Errorf("expected an empty city, got %s", city)
+	}
+	if point := o.GetCustomer().GetAddress().GetPoint(); point != (Point{}) {
+		t.Errorf("expected the zero point, got %v", point)
+	}
+	if tags := o.GetCustomer().GetTags(); tags != nil {
+		t.Errorf("expected nil tags, got %v", tags)
+	}
+
+	var p *Pair[string, *Order]
+	if p.GetKey() != "" || p.GetValue() != nil {
+		t.Errorf("got %s %v", p.GetKey(), p.GetValue())
+	}
+}
+
+func TestChaining(t *testing.T) {
+	o := &Order{ID: 1, Customer: &Customer{Name: "Alice", Address: &Address{City: "Paris"}}}
+	if city := o.GetCustomer().GetAddress().GetCity(); city != "Paris" {
+		t.Errorf("expected Paris, got %s", city)
+	}
+}
+
+func TestNilVariable(t *testing.T) {
+	currentOrder = nil
+	if GetID() != 0 || GetCustomer() != nil || GetItems() != nil {
+		t.Errorf("got %d %v %v", GetID(), GetCustomer(), GetItems())
+	}
+
+	currentOrder = &Order{ID: 2}
+	defer func() { currentOrder = nil }()
+	if GetID() != 2 {
+		t.Errorf("expected 2, got %d", GetID())
+	}
+}
+
+func TestNonPointerVariable(t *testing.T) {
+	homeAddress = Address{City: "Berlin"}
+	if GetCity() != "Berlin" {
+		t.Errorf("expected Berlin, got %s", GetCity())
+	}
+}
+
+func TestNilEmbeddedPointers(t *testing.T) {
+	s := &Shipment{ID: 1}
+	if s.GetWeight() != 0 || s.GetText() != "" {
+		t.Errorf("got %v %s", s.GetWeight(), s.GetText())
+	}
+	s.Parcel = &Parcel{Weight: 2}
+	if s.GetWeight() != 2 || s.GetText() != "" {
+		t.Errorf("got %v %s", s.GetWeight(), s.GetText())
+	}
+	s.Label = &Label{Text: "fragile"}
+	if s.GetText() != "fragile" {
+		t.Errorf("expected fragile, got %s", s.GetText())
+	}
+
+	lastShipment = Shipment{ID: 2}
+	defer func() { lastShipment = Shipment{} }()
+	if GetLastWeight() != 0 || GetLastText() != "" {
+		t.Errorf("got %v %s", GetLastWeight(), GetLastText())
+	}
+}
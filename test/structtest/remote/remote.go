@@ -0,0 +1,9 @@
+package remote
+
+import "time"
+
+type Config struct {
+	Host    string
+	Timeout time.Duration
+	secret  string
+}
@@ -1,10 +1,9 @@
-// structtest contains only cases we support. Other cases like the following are
-// related to type inference which is beyond the scope of this package.
-//
-//	type equal = struct_type_from_other_package_or_current_package
-//	type alias struct_type_from_other_package_or_current_package
+// structtest contains the cases of struct types, including the aliases and
+// defined types of other structs.
 package structtest
 
+import "github.com/yujiachen-y/goaccessor/test/structtest/remote"
+
 type S struct{}
 
 //go:generate go run ../../. -t Normal -a -i a,B,c,D,e,f
@@ -48,3 +47,34 @@ func (g *Generic[T, U]) SetU(u U) {
 type pure struct {
 	hello, World int
 }
+
+//go:generate go run ../../. -t V1Order,V2Order -a
+type V1Order struct {
+	ID    int
+	items []string
+}
+
+type V2Order V1Order
+
+//go:generate go run ../../. -t Alias -a -i a,e
+type Alias Normal
+
+//go:generate go run ../../. -t IntGeneric -a -i t,u
+type IntGeneric Generic[int, string]
+
+// Only exported fields of remote.Config are accessible.
+//
+//go:generate go run ../../. -t Remote -a
+type Remote remote.Config
+
+type aliased struct {
+	x, Y int
+}
+
+// GetX should not be generated for Equal
+func (a *aliased) GetX() int {
+	return a.x
+}
+
+//go:generate go run ../../. -t Equal -a
+type Equal = aliased
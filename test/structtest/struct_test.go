@@ -2,6 +2,7 @@ package structtest
 
 import (
 	"testing"
+	"time"
 
 	"github.com/yujiachen-y/goaccessor/test/utils"
 )
@@ -77,3 +78,50 @@ func TestPure(t *testing.T) {
 		}
 	}
 }
+
+func TestDefinedType(t *testing.T) {
+	v1, v2 := V1Order{}, V2Order{}
+	items := []string{"item"}
+	testMap := map[string]complex128{"test": complex(2, 0)}
+	a := Alias{}
+	i := IntGeneric{}
+	r := Remote{}
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&v1.ID, v1.SetID, 1),
+		utils.NewGetterVerifier(v1.GetID, 1),
+		utils.NewSliceSetterVerifier(&v1.items, v1.SetItems, items),
+		utils.NewSetterVerifier(&v2.ID, v2.SetID, 2),
+		utils.NewGetterVerifier(v2.GetID, 2),
+		utils.NewSliceGetterVerifier(v2.GetItems, nil),
+		utils.NewSetterVerifier(&a.a, a.SetA, 3),
+		utils.NewGetterVerifier(a.GetA, 3),
+		utils.NewMapSetterVerifier(&a.e, a.SetE, testMap),
+		utils.NewMapGetterVerifier(a.GetE, testMap),
+		utils.NewSetterVerifier(&i.t, i.SetT, 4),
+		utils.NewGetterVerifier(i.GetT, 4),
+		utils.NewSetterVerifier(&i.u, i.SetU, "5"),
+		utils.NewGetterVerifier(i.GetU, "5"),
+		utils.NewSetterVerifier(&r.Host, r.SetHost, "6"),
+		utils.NewGetterVerifier(r.GetHost, "6"),
+		utils.NewSetterVerifier(&r.Timeout, r.SetTimeout, time.Second),
+		utils.NewGetterVerifier(r.GetTimeout, time.Second),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
+
+func TestAlias(t *testing.T) {
+	e := Equal{x: 1}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(e.GetX, 1),
+		utils.NewSetterVerifier(&e.x, e.SetX, 2),
+		utils.NewSetterVerifier(&e.Y, e.SetY, 3),
+		utils.NewGetterVerifier(e.GetY, 3),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// synctest contains the accessors guarded by the locks in sync mode.
+package synctest
+
+import "sync"
+
+//go:generate go run ../../. -t Counter -a -l mu
+type Counter struct {
+	mu    sync.RWMutex
+	count int
+	name  string
+	//goaccessor:lock tagsMu
+	tags   []string
+	tagsMu *sync.Mutex
+}
+
+//go:generate go run ../../. -t Config -g -sy
+type Config struct {
+	sync.Mutex
+	addr string
+}
+
+//go:generate go run ../../. -t total -a -sy
+var total int
+
+//go:generate go run ../../. -t limit -a -l limitMu
+var limit int
+
+var limitMu sync.Mutex
+
+type Settings struct {
+	Debug bool
+}
+
+//go:generate go run ../../. -t settings -f -a -sy
+var settings Settings
@@ -0,0 +1,89 @@
+package synctest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+// verifyGuarded verifies that the accessor waits for the lock.
+func verifyGuarded(t *testing.T, name string, l sync.Locker, accessor func()) {
+	t.Helper()
+	l.Lock()
+	done := make(chan struct{})
+	go func() {
+		accessor()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Errorf("%s isn't guarded by the lock", name)
+	case <-time.After(10 * time.Millisecond):
+	}
+	l.Unlock()
+	<-done
+}
+
+func TestCounter(t *testing.T) {
+	c := &Counter{count: 1, name: "test2", tagsMu: &sync.Mutex{}}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(c.GetCount, 1),
+		utils.NewSetterVerifier(&c.name, c.SetName, "test3"),
+		utils.NewGetterVerifier(c.GetName, "test3"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	verifyGuarded(t, "SetCount", &c.mu, func() { c.SetCount(4) })
+	verifyGuarded(t, "GetCount", &c.mu, func() { c.GetCount() })
+	verifyGuarded(t, "GetTags", c.tagsMu, func() { c.GetTags() })
+
+	// the getters share the read lock
+	c.mu.RLock()
+	if c.GetCount() != 4 {
+		t.Errorf("expected 4, got %d", c.GetCount())
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.SetCount(c.GetCount() + 1)
+			c.SetTags([]string{c.GetName()})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConfig(t *testing.T) {
+	c := &Config{addr: "test1"}
+	verifyGuarded(t, "GetAddr", c, func() { c.GetAddr() })
+	if err := utils.NewGetterVerifier(c.GetAddr, "test1")(); err != nil {
+		t.Errorf("got error: %s", err.Error())
+	}
+}
+
+func TestVariables(t *testing.T) {
+	for _, verifier := range []utils.Verifier{
+		utils.NewSetterVerifier(&total, SetTotal, 1),
+		utils.NewGetterVerifier(GetTotal, 1),
+		utils.NewSetterVerifier(&limit, SetLimit, 2),
+		utils.NewGetterVerifier(GetLimit, 2),
+		utils.NewSetterVerifier(&settings.Debug, SetDebug, true),
+		utils.NewGetterVerifier(GetDebug, true),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	verifyGuarded(t, "SetTotal", &totalMu, func() { SetTotal(3) })
+	verifyGuarded(t, "GetLimit", &limitMu, func() { GetLimit() })
+	verifyGuarded(t, "SetDebug", &settingsMu, func() { SetDebug(false) })
+}
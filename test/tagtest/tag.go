@@ -0,0 +1,21 @@
+// tagtest contains the fields configured by the accessor struct tags, which
+// override the options of the directives.
+package tagtest
+
+//go:generate go run ../../. -t User -a -pr
+type User struct {
+	id       int    `accessor:"readonly,name=ID"`
+	name     string `db:"name" accessor:"get,set"`
+	password string `accessor:"-"`
+	email    string `accessor:"set"`
+	token    string `accessor:"get,unexported"`
+	age      int
+	Profile
+}
+
+type Profile struct {
+	bio string `accessor:"readonly"`
+}
+
+//go:generate go run ../../. -t admin -f -g -p admin
+var admin User
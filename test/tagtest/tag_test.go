@@ -0,0 +1,48 @@
+package tagtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yujiachen-y/goaccessor/test/utils"
+)
+
+func TestUser(t *testing.T) {
+	u := &User{id: 1, token: "test2", Profile: Profile{bio: "test3"}}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(u.GetID, 1),
+		utils.NewSetterVerifier(&u.name, u.SetName, "test4"),
+		utils.NewGetterVerifier(u.GetName, "test4"),
+		utils.NewSetterVerifier(&u.email, u.SetEmail, "test5"),
+		utils.NewGetterVerifier(u.getToken, "test2"),
+		utils.NewSetterVerifier(&u.age, u.SetAge, 6),
+		utils.NewGetterVerifier(u.GetAge, 6),
+		utils.NewGetterVerifier(u.GetBio, "test3"),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+
+	for _, name := range []string{"GetId", "SetID", "GetPassword", "SetPassword", "GetEmail", "GetToken", "SetToken", "SetBio", "GetProfile"} {
+		if _, ok := reflect.TypeOf(u).MethodByName(name); ok {
+			t.Errorf("unexpected method %s", name)
+		}
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	admin = User{id: 7, name: "test8", token: "test9", age: 10}
+	for _, verifier := range []utils.Verifier{
+		utils.NewGetterVerifier(GetAdminID, 7),
+		utils.NewGetterVerifier(GetAdminName, "test8"),
+		utils.NewSetterVerifier(&admin.name, SetAdminName, "test11"),
+		utils.NewSetterVerifier(&admin.email, SetAdminEmail, "test12"),
+		utils.NewGetterVerifier(getAdminToken, "test9"),
+		utils.NewGetterVerifier(GetAdminAge, 10),
+	} {
+		if err := verifier(); err != nil {
+			t.Errorf("got error: %s", err.Error())
+		}
+	}
+}
@@ -5,4 +5,7 @@ set -e
 go generate ./...
 
 go test ./...
- 
\ No newline at end of file
+
+# the accessors guarded by locks and sync/atomic types are checked for data
+# races as well
+go test -race ./...
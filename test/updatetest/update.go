@@ -0,0 +1,21 @@
+// updatetest contains the package level configuration variables updated by
+// the read-copy-update helpers.
+package updatetest
+
+type Config struct {
+	Addr    string
+	Retries int
+	Tags    []string
+}
+
+//go:generate go run ../../. -t config -a -up
+var config = Config{Addr: "localhost"}
+
+//go:generate go run ../../. -t settings -g -uc
+var settings = &Config{Addr: "remote"}
+
+//go:generate go run ../../. -t counter -g -up -ur 3
+var counter int
+
+//go:generate go run ../../. -t name -a -uc -ur 5
+var name = "test"
@@ -0,0 +1,94 @@
+package updatetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// updateConcurrently applies the update n times in parallel.
+func updateConcurrently(n int, update func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			update()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUpdateConfig(t *testing.T) {
+	updateConcurrently(100, func() {
+		UpdateConfig(func(old Config) Config {
+			old.Retries++
+			// the slices are copied before being modified
+			old.Tags = append(old.Tags[:len(old.Tags):len(old.Tags)], "tag")
+			return old
+		})
+	})
+	if c := GetConfig(); c.Addr != "localhost" || c.Retries != 100 || len(c.Tags) != 100 {
+		t.Errorf("got %s, %d and %d tags", c.Addr, c.Retries, len(c.Tags))
+	}
+
+	SetConfig(Config{Addr: "test"})
+	if GetConfig().Addr != "test" || SwapConfig(Config{}).Addr != "test" {
+		t.Errorf("got %s", GetConfig().Addr)
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	updateConcurrently(100, func() {
+		_, err := UpdateSettings(context.Background(), func(old *Config) *Config {
+			c := *old
+			c.Retries++
+			return &c
+		})
+		if err != nil {
+			t.Errorf("UpdateSettings: %s", err.Error())
+		}
+	})
+	if s := GetSettings(); s.Addr != "remote" || s.Retries != 100 {
+		t.Errorf("got %s and %d", s.Addr, s.Retries)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := UpdateSettings(ctx, func(old *Config) *Config { return nil }); err != context.Canceled || GetSettings() == nil {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUpdateCounter(t *testing.T) {
+	v, err := UpdateCounter(func(old int) int { return old + 1 })
+	if v != 1 || err != nil || GetCounter() != 1 {
+		t.Errorf("got %d and %v", v, err)
+	}
+
+	// the update never applies if the counter keeps changing
+	_, err = UpdateCounter(func(old int) int {
+		counterAtomic.Add(1)
+		return old
+	})
+	if err == nil || GetCounter() != 4 {
+		t.Errorf("expected error after 3 attempts, got %v and %d", err, GetCounter())
+	}
+}
+
+func TestUpdateName(t *testing.T) {
+	updateConcurrently(10, func() {
+		// the attempts are limited, but each of them succeeds eventually
+		for {
+			if _, err := UpdateName(context.Background(), func(old string) string { return old + "!" }); err == nil {
+				return
+			}
+		}
+	})
+	if GetName() != "test!!!!!!!!!!" {
+		t.Errorf("got %s", GetName())
+	}
+	if SwapName("x") != "test!!!!!!!!!!" || !CompareAndSwapName("x", "y") || CompareAndSwapName("x", "z") || GetName() != "y" {
+		t.Errorf("got %s", GetName())
+	}
+}
@@ -0,0 +1,24 @@
+// validatetest contains the setters checking the constraints in the validate
+// tags of the fields, and the Validate methods checking all of them.
+package validatetest
+
+import "sync"
+
+type Level string
+
+//go:generate go run ../../. -t Account -g -s -va -sy -e mu -if AccountAccessor
+type Account struct {
+	mu sync.RWMutex
+
+	Name    string   `validate:"nonempty,max=8,regexp=^[a-z]+(,[a-z]+)?$"`
+	Age     int      `validate:"min=1,max=150"`
+	Score   float64  `validate:"min=-1.5"`
+	Level   Level    `validate:"oneof=low mid high"`
+	Port    uint16   `validate:"oneof=80 443"`
+	Tags    []string `validate:"min=1,max=3" accessor:"set"`
+	Owner   *Account `validate:"nonempty"`
+	Comment string
+}
+
+//go:generate go run ../../. -t defaultAccount -f -s -va -e mu
+var defaultAccount Account
@@ -0,0 +1,94 @@
+package validatetest
+
+import (
+	"strings"
+	"testing"
+)
+
+var _ AccountAccessor = &Account{}
+
+func TestSetters(t *testing.T) {
+	a := &Account{}
+	for _, tt := range []struct {
+		name string
+		set  func() error
+		err  string
+	}{
+		{"empty name", func() error { return a.SetName("") }, "Name must not be empty"},
+		{"long name", func() error { return a.SetName("abcdefghi") }, "Name must have at most 8 characters, got 9"},
+		{"invalid name", func() error { return a.SetName("Bob") }, `Name must match ^[a-z]+(,[a-z]+)?$, got "Bob"`},
+		{"young", func() error { return a.SetAge(0) }, "Age must be at least 1, got 0"},
+		{"old", func() error { return a.SetAge(151) }, "Age must be at most 150, got 151"},
+		{"low score", func() error { return a.SetScore(-2) }, "Score must be at least -1.5, got -2"},
+		{"level", func() error { return a.SetLevel("top") }, "Level must be one of low, mid, high, got top"},
+		{"port", func() error { return a.SetPort(8080) }, "Port must be one of 80, 443, got 8080"},
+		{"no tags", func() error { return a.SetTags(nil) }, "Tags must have at least 1 elements, got 0"},
+		{"many tags", func() error { return a.SetTags([]string{"a", "b", "c", "d"}) }, "Tags must have at most 3 elements, got 4"},
+		{"no owner", func() error { return a.SetOwner(nil) }, "Owner must not be empty"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.set()
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+	if a.Name != "" || a.Age != 0 || a.Score != 0 || a.Level != "" || a.Port != 0 || a.Tags != nil || a.Owner != nil {
+		t.Errorf("expected the invalid values not to be set, got %+v", a)
+	}
+
+	for _, err := range []error{
+		a.SetName("ab,cd"),
+		a.SetAge(30),
+		a.SetScore(-1.5),
+		a.SetLevel("mid"),
+		a.SetPort(443),
+		a.SetTags([]string{"a"}),
+		a.SetOwner(a),
+	} {
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	a.SetComment("no constraints")
+	if a.GetName() != "ab,cd" || a.GetAge() != 30 || a.GetLevel() != "mid" || a.GetPort() != 443 || a.GetOwner() != a {
+		t.Errorf("unexpected account %+v", a)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	a := &Account{Name: "bob", Age: 30, Level: "low", Port: 80, Tags: []string{"x"}}
+	a.Owner = a
+	if err := a.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	err := (&Account{Age: 200, Score: -2}).Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	want := []string{
+		"Name must not be empty",
+		`Name must match ^[a-z]+(,[a-z]+)?$, got ""`,
+		"Age must be at most 150, got 200",
+		"Score must be at least -1.5, got -2",
+		"Level must be one of low, mid, high, got ",
+		"Port must be one of 80, 443, got 0",
+		"Tags must have at least 1 elements, got 0",
+		"Owner must not be empty",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected errors\n%s\ngot\n%s", strings.Join(want, "\n"), err)
+	}
+}
+
+func TestFieldSetters(t *testing.T) {
+	if err := SetName("Bob"); err == nil {
+		t.Error("expected an error")
+	}
+	if err := SetAge(42); err != nil || defaultAccount.Age != 42 {
+		t.Errorf("unexpected error %v, age %d", err, defaultAccount.Age)
+	}
+	SetComment("no constraints")
+}
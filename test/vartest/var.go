@@ -1,5 +1,11 @@
 package vartest
 
+import (
+	"math"
+	"math/cmplx"
+	"time"
+)
+
 //go:generate go run ../../. -t i -a
 var i int
 
@@ -39,14 +45,33 @@ var world *Struct
 //go:generate go run ../../. -t pure1,Pure2 -a -pg
 var pure1, Pure2 int
 
-/*
-Supporting type inference is beyond the scope.
+func complexSqrt(x complex128) (complex128, complex128) {
+	r := cmplx.Sqrt(x)
+	return r, -r
+}
+
+var (
+	entries             = map[string]int{"name": 1}
+	value   interface{} = Struct{}
+)
 
+//go:generate go run ../../. -t re,im -a
 var re, im = complexSqrt(-1)
-var _, found = entries[name]  // map lookup; only interested in "found"
-var d = math.Sin(0.5)  // d is float64
-var t, ok = x.(T)      // t is T, ok is bool
 
-var a = pkg.Struct.Field
-var a = 1 + 2
-*/
+//go:generate go run ../../. -t found -a
+var _, found = entries["name"] // map lookup; only interested in "found"
+
+//go:generate go run ../../. -t d -a
+var d = math.Sin(0.5) // d is float64
+
+//go:generate go run ../../. -t st,ok -a
+var st, ok = value.(Struct) // st is Struct, ok is bool
+
+//go:generate go run ../../. -t loc -a
+var loc = time.UTC.String()
+
+//go:generate go run ../../. -t sum -a
+var sum = 1 + 2
+
+//go:generate go run ../../. -t month -a
+var month = time.Now().Month() // month is time.Month
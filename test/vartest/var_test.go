@@ -2,6 +2,7 @@ package vartest
 
 import (
 	"testing"
+	"time"
 
 	"github.com/yujiachen-y/goaccessor/test/utils"
 )
@@ -26,6 +27,15 @@ func TestGetVar(t *testing.T) {
 		utils.NewGetterVerifier(GetHello, hello),
 		utils.NewGetterVerifier(GetWorld, world),
 		utils.NewGetterVerifier(Pure1, pure1),
+		utils.NewGetterVerifier(GetRe, re),
+		utils.NewGetterVerifier(GetIm, im),
+		utils.NewGetterVerifier(GetFound, found),
+		utils.NewGetterVerifier(GetD, d),
+		utils.NewGetterVerifier(GetSt, st),
+		utils.NewGetterVerifier(GetOk, ok),
+		utils.NewGetterVerifier(GetLoc, loc),
+		utils.NewGetterVerifier(GetSum, sum),
+		utils.NewGetterVerifier(GetMonth, month),
 	} {
 		if err := verifier(); err != nil {
 			t.Errorf("got err: %s", err.Error())
@@ -54,6 +64,15 @@ func TestSetVar(t *testing.T) {
 		utils.NewPointSetterVerifier(&world, SetWorld, Struct{}),
 		utils.NewSetterVerifier(&pure1, SetPure1, 19),
 		utils.NewSetterVerifier(&Pure2, SetPure2, 20),
+		utils.NewSetterVerifier(&re, SetRe, 21i),
+		utils.NewSetterVerifier(&im, SetIm, -21i),
+		utils.NewSetterVerifier(&found, SetFound, false),
+		utils.NewSetterVerifier(&d, SetD, 22.23),
+		utils.NewSetterVerifier(&st, SetSt, Struct{}),
+		utils.NewSetterVerifier(&ok, SetOk, false),
+		utils.NewSetterVerifier(&loc, SetLoc, "Local"),
+		utils.NewSetterVerifier(&sum, SetSum, 24),
+		utils.NewSetterVerifier(&month, SetMonth, time.December),
 	} {
 		if err := verifier(); err != nil {
 			t.Errorf("got err: %s", err.Error())
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validation is a constraint of a field declared by its validate tag, which is
+// checked by the setter and Validate in validate mode.
+type Validation struct {
+	// Rule is "min", "max", "oneof", "regexp", or "nonempty".
+	Rule string
+	// Args are the numbers or the strings of the rule, or the pattern of
+	// regexp.
+	Args []string
+	// Kind is how the value is checked, which is "number", "string",
+	// "length", or "nil" according to the type of the field.
+	Kind string
+}
+
+// validationKind returns how the values of t are checked, or "" if they can't
+// be checked.
+func validationKind(t types.Type) string {
+	if _, ok := t.(*types.TypeParam); ok {
+		return ""
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsNumeric != 0 && u.Info()&types.IsComplex == 0:
+			return "number"
+		case u.Info()&types.IsString != 0:
+			return "string"
+		}
+	case *types.Slice, *types.Map, *types.Chan:
+		return "length"
+	case *types.Pointer, *types.Interface, *types.Signature:
+		return "nil"
+	}
+	return ""
+}
+
+// parseValidations parses the validate tags of the fields in validate mode.
+// The tags are left alone in the other modes, since they may be used by
+// other tools in their own syntax.
+func (g *Generator) parseValidations() error {
+	for i, field := range g.Fields {
+		validations, err := parseValidateTag(field.ValidateTag, field.ValidateKind, field.ValidateBasic)
+		if err != nil {
+			return fmt.Errorf("can't validate field %s of '%s', whose type is %s: %w", field.Name, g.Name, field.Type, err)
+		}
+		g.Fields[i].Validations = validations
+	}
+	return nil
+}
+
+// validationBasic returns the name of the underlying basic type of t if its
+// values are checked as numbers, or "" otherwise.
+func validationBasic(t types.Type) string {
+	if validationKind(t) != "number" {
+		return ""
+	}
+	return t.Underlying().(*types.Basic).Name()
+}
+
+// parseValidateTag parses the value of a validate tag of a field whose values
+// are checked as kind, and the numbers are of the basic type if they're
+// checked as numbers. The value is a comma-separated list of "min=n",
+// "max=n", "oneof=a b c", "nonempty", and "regexp=pattern", which must be the
+// last one since the pattern may contain commas.
+func parseValidateTag(value, kind, basic string) ([]Validation, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("unsupported type of validate tag %q", value)
+	}
+
+	var validations []Validation
+	for rest := value; rest != ""; {
+		var option string
+		if strings.HasPrefix(rest, "regexp=") {
+			option, rest = rest, ""
+		} else {
+			option, rest, _ = strings.Cut(rest, ",")
+		}
+		rule, arg, _ := strings.Cut(option, "=")
+		v := Validation{Rule: rule, Kind: kind}
+		switch {
+		case rule == "nonempty" && arg == "":
+		case (rule == "min" || rule == "max") && kind != "nil":
+			if kind == "number" {
+				if err := checkNumber(arg, basic); err != nil {
+					return nil, fmt.Errorf("invalid %s in validate tag %q: %w", rule, value, err)
+				}
+			} else if n, err := strconv.Atoi(arg); err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid length %q of %s in validate tag %q", arg, rule, value)
+			}
+			v.Args = []string{arg}
+		case rule == "oneof" && (kind == "number" || kind == "string"):
+			for _, a := range strings.Fields(arg) {
+				if kind == "string" {
+					a = strconv.Quote(a)
+				} else if err := checkNumber(a, basic); err != nil {
+					return nil, fmt.Errorf("invalid oneof in validate tag %q: %w", value, err)
+				}
+				v.Args = append(v.Args, a)
+			}
+			if len(v.Args) == 0 {
+				return nil, fmt.Errorf("empty oneof in validate tag %q", value)
+			}
+		case rule == "regexp" && kind == "string":
+			if _, err := regexp.Compile(arg); err != nil {
+				return nil, fmt.Errorf("invalid regexp in validate tag %q: %w", value, err)
+			}
+			v.Args = []string{arg}
+		default:
+			return nil, fmt.Errorf("unsupported option %q in validate tag %q", option, value)
+		}
+		validations = append(validations, v)
+	}
+	return validations, nil
+}
+
+// checkNumber checks that the number is a constant which can be represented
+// by the basic type, so it can be compared with the values of the type in the
+// generated code.
+func checkNumber(number, basic string) error {
+	if f, err := strconv.ParseFloat(number, 64); err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("invalid number %q", number)
+	}
+	if _, err := types.Eval(token.NewFileSet(), nil, token.NoPos, basic+"("+number+")"); err != nil {
+		return fmt.Errorf("%s can't be represented by %s", number, basic)
+	}
+	return nil
+}
+
+// validationCodeLines returns the statements checking the value v of the
+// field, which call fail with the error expression if a check fails, and the
+// declarations of the patterns they use, which precede the function.
+func (g *Generator) validationCodeLines(field Field, v string, fail func(err string) string) (decls, cl codeLines, err error) {
+	validations := g.validations(field)
+	if len(validations) == 0 {
+		return nil, nil, nil
+	}
+	declared := func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	}
+	errorf := func(format string, a ...string) string {
+		format = field.Name + " " + format
+		if len(a) == 0 {
+			return fmt.Sprintf("%s.New(%s)", g.AddImport("errors", "errors", declared), strconv.Quote(format))
+		}
+		fmtName := g.AddImport("fmt", "fmt", declared)
+		return fmt.Sprintf("%s.Errorf(%s)", fmtName, strings.Join(append([]string{strconv.Quote(format)}, a...), ", "))
+	}
+	// the values of the named string types are converted for the functions
+	str := v
+	if field.Type != "string" {
+		str = "string(" + v + ")"
+	}
+
+	for _, validation := range validations {
+		switch validation.Rule {
+		case "min", "max":
+			op, word := "<", "at least"
+			if validation.Rule == "max" {
+				op, word = ">", "at most"
+			}
+			bound := validation.Args[0]
+			switch validation.Kind {
+			case "number":
+				cl = cl.Append("        if %s %s %s {", v, op, bound)
+				cl = cl.Append("                %s", fail(errorf(fmt.Sprintf("must be %s %s, got %%v", word, bound), v)))
+			case "string":
+				utf8Name := g.AddImport("utf8", "unicode/utf8", declared)
+				n := fmt.Sprintf("%s.RuneCountInString(%s)", utf8Name, str)
+				cl = cl.Append("        if n := %s; n %s %s {", n, op, bound)
+				cl = cl.Append("                %s", fail(errorf(fmt.Sprintf("must have %s %s characters, got %%d", word, bound), "n")))
+			default:
+				cl = cl.Append("        if n := len(%s); n %s %s {", v, op, bound)
+				cl = cl.Append("                %s", fail(errorf(fmt.Sprintf("must have %s %s elements, got %%d", word, bound), "n")))
+			}
+			cl = cl.Append("        }")
+		case "oneof":
+			names := strings.Join(validation.Args, ", ")
+			if validation.Kind == "string" {
+				var values []string
+				for _, a := range validation.Args {
+					s, _ := strconv.Unquote(a)
+					values = append(values, s)
+				}
+				names = strings.Join(values, ", ")
+			}
+			cl = cl.Append("        switch %s {", v)
+			cl = cl.Append("        case %s:", strings.Join(validation.Args, ", "))
+			cl = cl.Append("        default:")
+			cl = cl.Append("                %s", fail(errorf(fmt.Sprintf("must be one of %s, got %%v", strings.ReplaceAll(names, "%", "%%")), v)))
+			cl = cl.Append("        }")
+		case "regexp":
+			pattern, patternCodeLines, err := g.patternName(field, validation.Args[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			decls = append(decls, patternCodeLines...)
+			cl = cl.Append("        if !%s.MatchString(%s) {", pattern, str)
+			cl = cl.Append("                %s", fail(errorf(fmt.Sprintf("must match %s, got %%q", strings.ReplaceAll(validation.Args[0], "%", "%%")), v)))
+			cl = cl.Append("        }")
+		case "nonempty":
+			switch validation.Kind {
+			case "number":
+				cl = cl.Append("        if %s == 0 {", v)
+			case "nil":
+				cl = cl.Append("        if %s == nil {", v)
+			default:
+				cl = cl.Append("        if len(%s) == 0 {", v)
+			}
+			cl = cl.Append("                %s", fail(errorf("must not be empty")))
+			cl = cl.Append("        }")
+		}
+	}
+	return
+}
+
+// setterValidationCodeLines returns the statements of the setter checking the
+// new value of the field, which return the error if a check fails, and the
+// declarations of the patterns they use.
+func (g *Generator) setterValidationCodeLines(field Field) (decls, cl codeLines, err error) {
+	return g.validationCodeLines(field, g.newValueName(), func(err string) string {
+		return "return " + err
+	})
+}
+
+// validations returns the validations of the field in validate mode.
+func (g *Generator) validations(field Field) []Validation {
+	if !g.opts.validate {
+		return nil
+	}
+	return field.Validations
+}
+
+// patternName returns the package level variable of the compiled pattern of
+// the field, and its declaration if it isn't declared yet. The declaration is
+// placed before the first function using it.
+func (g *Generator) patternName(field Field, pattern string) (string, codeLines, error) {
+	if name, ok := g.patterns[field.Name]; ok {
+		return name, nil, nil
+	}
+	name := lower(g.Name) + upper(field.Name) + "Pattern"
+	patternName, err := g.resolve(name, "variable")
+	if err != nil {
+		return "", nil, err
+	}
+	if patternName == "" {
+		return "", nil, fmt.Errorf("%s already exists, which is the pattern of field %s of '%s'", name, field.Name, g.Name)
+	}
+	if g.patterns == nil {
+		g.patterns = make(map[string]string)
+	}
+	g.patterns[field.Name] = patternName
+
+	regexpName := g.AddImport("regexp", "regexp", func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	})
+	var cl codeLines
+	cl = cl.Append("")
+	cl = cl.Append("// %s validates %s of %s.", patternName, field.Name, g.Name)
+	cl = cl.Append("var %s = %s.MustCompile(%s)", patternName, regexpName, strconv.Quote(pattern))
+	return patternName, cl, nil
+}
+
+// getValidateCodeLines returns Validate of the struct type in validate mode,
+// which checks every field with a validate tag or a validate hook, and joins
+// the errors of all the fields.
+func (g *Generator) getValidateCodeLines() (cl codeLines, err error) {
+	var body codeLines
+	recv := g.getReceiverName()
+	var patterns codeLines
+	for _, field := range g.Fields {
+		if field.Promoted {
+			continue
+		}
+		decls, validationCodeLines, err := g.validationCodeLines(field, recv+"."+field.Name, func(err string) string {
+			return "errs = append(errs, " + err + ")"
+		})
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, decls...)
+		body = append(body, validationCodeLines...)
+		// the validate hook checks the field as well
+		if h := g.hooks(field)["validate"]; h.Err {
+			body = body.Append("        if err := %s.%s(%s.%s); err != nil {", recv, h.Kind+upper(h.Field), recv, field.Name)
+			body = body.Append("                errs = append(errs, err)")
+			body = body.Append("        }")
+		}
+	}
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	name, err := g.resolveName("Validate")
+	if err != nil {
+		return nil, err
+	}
+	cl = append(cl, patterns...)
+	cl = cl.Append("")
+	if name == "" {
+		return cl.Append("// Validate already exists"), nil
+	}
+	errorsName := g.AddImport("errors", "errors", func(name string) bool {
+		_, ok := g.Declared[name]
+		return ok
+	})
+	cl = cl.Append("// %s checks the constraints in the validate tags and the validate hooks of", name)
+	cl = cl.Append("// the fields, and returns the errors of all the failed checks joined.")
+	cl = cl.Append("func (%s *%s) %s() error {", recv, g.getReceiverType(), name)
+	cl = append(cl, g.lock.codeLines(false)...)
+	cl = cl.Append("        var errs []error")
+	cl = append(cl, body...)
+	cl = cl.Append("        return %s.Join(errs...)", errorsName)
+	cl = cl.Append("}")
+	return
+}